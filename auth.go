@@ -0,0 +1,123 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Token is an access token issued by the instance manager.
+type Token struct {
+	Access  string
+	Refresh string
+	// Expiry is the time the access token expires at. The zero time means
+	// the expiry is unknown.
+	Expiry time.Time
+}
+
+// Authenticator obtains an access token from the instance manager at url.
+type Authenticator interface {
+	Authenticate(client *http.Client, url string) (*Token, error)
+}
+
+type tokenBody struct {
+	Token        string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// requestToken sends the request and decodes the token in the response.
+func requestToken(client *http.Client, req *http.Request, op string, want int) (*Token, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		return nil, newError(op, want, resp)
+	}
+
+	tb := &tokenBody{}
+	if err := json.NewDecoder(resp.Body).Decode(tb); err != nil {
+		return nil, err
+	}
+	if tb.Token == "" {
+		return nil, errors.New(op + " failed: token is empty")
+	}
+	t := &Token{Access: tb.Token, Refresh: tb.RefreshToken}
+	if tb.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(tb.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+// BasicAuth authenticates with the email and password of a user. Service
+// accounts, users dedicated to automation, authenticate the same way.
+type BasicAuth struct {
+	User     string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(client *http.Client, url string) (*Token, error) {
+	req, err := http.NewRequest(http.MethodPost, url+"/tokens", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(a.User, a.Password)
+	return requestToken(client, req, "login", http.StatusCreated)
+}
+
+// BearerToken authenticates with a pre-issued access token. The token cannot
+// be renewed so the manager has to be recreated once it expires.
+type BearerToken struct {
+	Token string
+}
+
+func (a BearerToken) Authenticate(*http.Client, string) (*Token, error) {
+	if a.Token == "" {
+		return nil, errors.New("login failed: token is empty")
+	}
+	return &Token{Access: a.Token}, nil
+}
+
+// RefreshToken authenticates by exchanging a refresh token for an access
+// token. The refresh token is replaced if the instance manager issues a new
+// one.
+type RefreshToken struct {
+	mu    sync.Mutex
+	token string
+}
+
+func NewRefreshToken(token string) *RefreshToken {
+	return &RefreshToken{token: token}
+}
+
+func (a *RefreshToken) Authenticate(client *http.Client, url string) (*Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == "" {
+		return nil, errors.New("refresh failed: refresh token is empty")
+	}
+	body, err := json.Marshal(struct {
+		RefreshToken string `json:"refreshToken"`
+	}{a.token})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url+"/refresh", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	t, err := requestToken(client, req, "refresh", http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	if t.Refresh != "" {
+		a.token = t.Refresh
+	}
+	return t, nil
+}
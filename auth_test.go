@@ -0,0 +1,114 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManagerRenewsExpiringTokenWithRefreshToken(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/refresh":
+			var body struct {
+				RefreshToken string `json:"refreshToken"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			got = append(got, "refresh "+body.RefreshToken)
+			w.WriteHeader(http.StatusCreated)
+			// the first token expires right away to force a renewal
+			fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","expires_in":%d}`, len(got), len(got), len(got)*3600-3599)
+		default:
+			got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewManagerWithAuth(srv.URL, "", NewRefreshToken("refresh-0"), srv.Client())
+	if err := m.Login(); err != nil {
+		t.Fatalf("Login failed: %s", err)
+	}
+	if _, err := m.Me(); err != nil {
+		t.Fatalf("Me failed: %s", err)
+	}
+	if _, err := m.Me(); err != nil {
+		t.Fatalf("Me failed: %s", err)
+	}
+
+	want := []string{
+		"refresh refresh-0",
+		"refresh refresh-1",
+		"GET /me Bearer access-2",
+		"GET /me Bearer access-2",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requests mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestManagerRenewsTokenOnceForConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tokens" {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		mu.Lock()
+		logins++
+		n := logins
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		// the first token expires right away to force a renewal
+		fmt.Fprintf(w, `{"access_token":"access-%d","expires_in":%d}`, n, n*3600-3599)
+	}))
+	defer srv.Close()
+
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+	if err := m.Login(); err != nil {
+		t.Fatalf("Login failed: %s", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Me(); err != nil {
+				t.Errorf("Me failed: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if logins != 2 {
+		t.Errorf("logged in %d times, want 2", logins)
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	t.Setenv("IM_TOKEN", "secret")
+
+	auth, err := AuthConfig{Method: AuthToken, Env: "IM_TOKEN"}.Authenticator("")
+	if err != nil {
+		t.Fatalf("Authenticator failed: %s", err)
+	}
+	if diff := cmp.Diff(BearerToken{Token: "secret"}, auth); diff != "" {
+		t.Errorf("Authenticator mismatch (-want +got): %s\n", diff)
+	}
+
+	auth, err = AuthConfig{}.Authenticator("user")
+	if err != nil || auth != nil {
+		t.Errorf("Authenticator of password method = %v, %v, want nil, nil", auth, err)
+	}
+
+	if _, err := (AuthConfig{Method: AuthServiceAccount, Env: "IM_UNSET"}).Authenticator("ci"); err == nil {
+		t.Error("Authenticator with unset env var succeeded, want error")
+	}
+}
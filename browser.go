@@ -0,0 +1,44 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// OpenBrowser opens the URL in the browser set via the BROWSER environment
+// variable or the default browser of the operating system. It does not wait
+// for the browser to exit.
+func OpenBrowser(url string) error {
+	var cmds [][]string
+	if b := os.Getenv("BROWSER"); b != "" {
+		// BROWSER can hold a list of browsers to try in order
+		for _, b := range strings.Split(b, string(os.PathListSeparator)) {
+			if b = strings.TrimSpace(b); b != "" {
+				cmds = append(cmds, append(strings.Fields(b), url))
+			}
+		}
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		cmds = append(cmds, []string{"open", url})
+	case "windows":
+		cmds = append(cmds, []string{"rundll32", "url.dll,FileProtocolHandler", url})
+	default:
+		cmds = append(cmds, []string{"xdg-open", url})
+	}
+
+	var errs []string
+	for _, c := range cmds {
+		cmd := exec.Command(c[0], c[1:]...)
+		if err := cmd.Start(); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		go func() { _ = cmd.Wait() }()
+		return nil
+	}
+	return errors.New("failed to open browser: " + strings.Join(errs, "; "))
+}
@@ -0,0 +1,135 @@
+package instance
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Selector selects instances by their attributes. Empty attributes match any
+// instance.
+type Selector struct {
+	// Name is a glob pattern like "release-*" matched against the name.
+	Name   string
+	Group  string
+	Status string
+	// OlderThan selects instances created longer than the duration ago.
+	OlderThan time.Duration
+}
+
+// ParseSelector parses a selector like "name=release-*,group=qa". Supported
+// keys are name, group and status.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(term, "=")
+		if !ok {
+			return Selector{}, fmt.Errorf("invalid selector %q: expected format key=value", term)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		switch k {
+		case "name":
+			if _, err := path.Match(v, ""); err != nil {
+				return Selector{}, fmt.Errorf("invalid selector %q: %s", term, err)
+			}
+			sel.Name = v
+		case "group":
+			sel.Group = v
+		case "status":
+			sel.Status = v
+		default:
+			return Selector{}, fmt.Errorf("invalid selector %q: unknown key %q, expected name, group or status", term, k)
+		}
+	}
+	return sel, nil
+}
+
+// Empty reports whether the selector matches all instances.
+func (s Selector) Empty() bool {
+	return s == Selector{}
+}
+
+// Matches reports whether the instance is selected at time now.
+func (s Selector) Matches(in Instance, now time.Time) bool {
+	if s.Name != "" {
+		if ok, _ := path.Match(s.Name, in.Name); !ok {
+			return false
+		}
+	}
+	if s.Group != "" && s.Group != in.GroupName {
+		return false
+	}
+	if s.Status != "" && !strings.EqualFold(s.Status, in.Status) {
+		return false
+	}
+	if s.OlderThan > 0 && now.Sub(in.CreatedAt) < s.OlderThan {
+		return false
+	}
+	return true
+}
+
+// Select returns the instances matching the selector sorted by name.
+func (m *Manager) Select(sel Selector) ([]Instance, error) {
+	ins, err := m.Instances()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var selected []Instance
+	for _, in := range ins {
+		if sel.Matches(in, now) {
+			selected = append(selected, in)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+	return selected, nil
+}
+
+// BulkResult is the result of an operation on one instance.
+type BulkResult struct {
+	Instance Instance
+	Err      error
+}
+
+// RunBulk runs the operation on each instance with at most parallel
+// operations running concurrently. The results are in the order of the
+// instances.
+func RunBulk(ins []Instance, parallel int, op func(Instance) error) []BulkResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]BulkResult, len(ins))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, in := range ins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, in Instance) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = BulkResult{Instance: in, Err: op(in)}
+		}(i, in)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// DeleteAll deletes the instances with at most parallel deletions running
+// concurrently.
+func (m *Manager) DeleteAll(ins []Instance, parallel int) []BulkResult {
+	return RunBulk(ins, parallel, func(in Instance) error {
+		return m.Delete(in.ID)
+	})
+}
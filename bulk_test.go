@@ -0,0 +1,111 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSelector(t *testing.T) {
+	now := time.Now()
+	ins := []Instance{
+		{Name: "release-1", GroupName: "qa", Status: "Running", CreatedAt: now.Add(-10 * day)},
+		{Name: "release-2", GroupName: "qa", Status: "Running", CreatedAt: now.Add(-time.Hour)},
+		{Name: "release-3", GroupName: "dev", Status: "Running", CreatedAt: now.Add(-10 * day)},
+		{Name: "feature", GroupName: "qa", Status: "Error", CreatedAt: now.Add(-10 * day)},
+	}
+
+	sel, err := ParseSelector("name=release-*, group=qa")
+	if err != nil {
+		t.Fatalf("ParseSelector failed: %s", err)
+	}
+	sel.OlderThan = 7 * day
+
+	var got []string
+	for _, in := range ins {
+		if sel.Matches(in, now) {
+			got = append(got, in.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"release-1"}, got); diff != "" {
+		t.Errorf("Matches mismatch (-want +got): %s\n", diff)
+	}
+
+	for _, in := range []string{"name", "stack=dhis2", "name=[a"} {
+		if _, err := ParseSelector(in); err == nil {
+			t.Errorf("ParseSelector(%q) expected an error", in)
+		}
+	}
+}
+
+func TestRunBulk(t *testing.T) {
+	ins := []Instance{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	var running, maxRunning int32
+
+	results := RunBulk(ins, 2, func(in Instance) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if in.ID == 3 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if maxRunning > 2 {
+		t.Errorf("ran %d operations concurrently, want at most 2", maxRunning)
+	}
+	for i, r := range results {
+		if r.Instance.ID != i+1 {
+			t.Errorf("results[%d] is for instance %d, want %d", i, r.Instance.ID, i+1)
+		}
+		if (r.Err != nil) != (r.Instance.ID == 3) {
+			t.Errorf("results[%d] has error %v", i, r.Err)
+		}
+	}
+}
+
+func TestDeleteInstancesPartiallyFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+		case "/instances/1":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.Error(w, "failed", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	im := NewManager(srv.URL, "user", "pw", srv.Client())
+	m := NewInstances(im, DefaultLayout, 0)
+
+	msg := m.deleteInstances([]Instance{{ID: 1, Name: "dev"}, {ID: 2, Name: "demo"}})()
+
+	changed, ok := msg.(instancesChangedMsg)
+	if !ok {
+		t.Fatalf("got %T, want instancesChangedMsg to show the deleted instance is gone", msg)
+	}
+	if changed.failed == nil || changed.failed.retry == nil {
+		t.Fatal("failed delete is not reported with a retry")
+	}
+
+	msg = m.deleteInstances([]Instance{{ID: 2, Name: "demo"}})()
+
+	if _, ok := msg.(errMsg); !ok {
+		t.Errorf("got %T, want errMsg as no instance was deleted", msg)
+	}
+}
@@ -0,0 +1,272 @@
+package instance
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTLs are the durations responses are served from the cache
+// without asking the instance manager per path prefix. Responses to other
+// GET requests are only cached if they can be revalidated.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"/stacks": day,
+}
+
+// CacheDir returns the directory responses of the instance manager at url are
+// cached in for the credentials of auth. Users and tokens do not share cached
+// responses as they may be allowed to see different resources.
+func CacheDir(url string, auth Authenticator) (string, error) {
+	dir, err := cacheRoot()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256([]byte(url + "\x00" + identity(auth)))
+	return filepath.Join(dir, hex.EncodeToString(h[:8])), nil
+}
+
+// identity returns what identifies the user authenticating with auth. Tokens
+// identify the user they were issued to. The refresh token is the one given
+// which stays the same across runs even if it is replaced while running.
+func identity(auth Authenticator) string {
+	switch a := auth.(type) {
+	case BasicAuth:
+		return "user\x00" + a.User
+	case BearerToken:
+		return "token\x00" + a.Token
+	case *RefreshToken:
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return "refresh-token\x00" + a.token
+	}
+	return fmt.Sprintf("%T", auth)
+}
+
+// ClearCache removes the cached responses of all instance managers and
+// users.
+func ClearCache() error {
+	dir, err := cacheRoot()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func cacheRoot() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dhis2-im", "http"), nil
+}
+
+// Cache is a http.RoundTripper caching responses to GET requests on disk.
+// Responses are served from the cache while they are younger than the TTL of
+// their path. Older responses are revalidated using their ETag or
+// Last-Modified header if the instance manager sent one. Requests changing a
+// resource evict its cached responses.
+type Cache struct {
+	transport http.RoundTripper
+	dir       string
+	ttls      map[string]time.Duration
+}
+
+// NewCache returns a cache storing responses in dir. The http.DefaultTransport
+// is used if transport is nil.
+func NewCache(transport http.RoundTripper, dir string, ttls map[string]time.Duration) *Cache {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Cache{transport: transport, dir: dir, ttls: ttls}
+}
+
+type cacheEntry struct {
+	StoredAt time.Time   `json:"storedAt"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		resp, err := c.transport.RoundTrip(req)
+		if err == nil && resp.StatusCode < 400 {
+			_ = os.RemoveAll(c.resourceDir(req))
+		}
+		return resp, err
+	}
+
+	path := c.entryPath(req)
+	e, _ := readCacheEntry(path)
+	if e != nil && time.Since(e.StoredAt) < c.ttl(req.URL.Path) {
+		reportCacheHit(req.Context())
+		return e.response(req), nil
+	}
+	if e != nil {
+		if etag := e.Header.Get("ETag"); etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		} else if lm := e.Header.Get("Last-Modified"); lm != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && e != nil {
+		resp.Body.Close()
+		e.StoredAt = time.Now()
+		_ = writeCacheEntry(path, e)
+		return e.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK || !c.cacheable(req.URL.Path, resp.Header) {
+		return resp, nil
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	_ = writeCacheEntry(path, &cacheEntry{
+		StoredAt: time.Now(),
+		Header:   resp.Header,
+		Body:     b,
+	})
+	return resp, nil
+}
+
+type cacheHitKey struct{}
+
+// withCacheHit returns a context in which the cache sets hit if it serves the
+// response to a request without sending it.
+func withCacheHit(ctx context.Context, hit *bool) context.Context {
+	return context.WithValue(ctx, cacheHitKey{}, hit)
+}
+
+func reportCacheHit(ctx context.Context) {
+	if hit, ok := ctx.Value(cacheHitKey{}).(*bool); ok {
+		*hit = true
+	}
+}
+
+// ttl returns the TTL of the longest path prefix matching the path.
+func (c *Cache) ttl(path string) time.Duration {
+	var ttl time.Duration
+	longest := -1
+	for prefix, d := range c.ttls {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			ttl, longest = d, len(prefix)
+		}
+	}
+	return ttl
+}
+
+func (c *Cache) cacheable(path string, h http.Header) bool {
+	if strings.Contains(h.Get("Cache-Control"), "no-store") {
+		return false
+	}
+	return c.ttl(path) > 0 || h.Get("ETag") != "" || h.Get("Last-Modified") != ""
+}
+
+// resourceDir returns the directory the responses of the resource, the first
+// segment of the path, are cached in.
+func (c *Cache) resourceDir(req *http.Request) string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	if resource == "" {
+		resource = "_"
+	}
+	return filepath.Join(c.dir, resource)
+}
+
+func (c *Cache) entryPath(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.URL.RequestURI()))
+	return filepath.Join(c.resourceDir(req), hex.EncodeToString(h[:])+".json")
+}
+
+func readCacheEntry(path string) (*cacheEntry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &cacheEntry{}
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func writeCacheEntry(path string, e *cacheEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// write to a temporary file first so that concurrent readers never see a
+	// partially written entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// response returns the cached response. Only successful responses are
+// cached.
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// ParseCacheTTLs parses TTLs per path prefix like {"/stacks": "7d"} and
+// merges them into a copy of the DefaultCacheTTLs.
+func ParseCacheTTLs(ttls map[string]string) (map[string]time.Duration, error) {
+	merged := make(map[string]time.Duration, len(DefaultCacheTTLs)+len(ttls))
+	for prefix, d := range DefaultCacheTTLs {
+		merged[prefix] = d
+	}
+	for prefix, s := range ttls {
+		d, err := ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache TTL of %s: %w", prefix, err)
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, errors.New("cache TTLs must be given per path like /stacks")
+		}
+		merged[prefix] = d
+	}
+	return merged, nil
+}
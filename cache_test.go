@@ -0,0 +1,134 @@
+package instance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCache(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("If-None-Match"))
+		switch r.URL.Path {
+		case "/stacks":
+			fmt.Fprint(w, `[]`)
+		case "/instances":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	cache := NewCache(srv.Client().Transport, t.TempDir(), map[string]time.Duration{"/stacks": time.Hour})
+	client := &http.Client{Transport: cache}
+
+	requests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/stacks", http.StatusOK},
+		// served from the cache
+		{http.MethodGet, "/stacks", http.StatusOK},
+		{http.MethodGet, "/instances", http.StatusOK},
+		// revalidated
+		{http.MethodGet, "/instances", http.StatusOK},
+		// evicts the cached stacks
+		{http.MethodDelete, "/stacks/1", http.StatusNoContent},
+		{http.MethodGet, "/stacks", http.StatusOK},
+	}
+	for _, r := range requests {
+		req, err := http.NewRequest(r.method, srv.URL+r.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %s", r.method, r.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != r.want {
+			t.Errorf("%s %s returned %d, want %d", r.method, r.path, resp.StatusCode, r.want)
+		}
+	}
+
+	want := []string{
+		"GET /stacks ",
+		"GET /instances ",
+		`GET /instances "v1"`,
+		"DELETE /stacks/1 ",
+		"GET /stacks ",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requests mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestLatencyOmitsCacheHits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+		case "/stacks/":
+			time.Sleep(20 * time.Millisecond)
+			fmt.Fprint(w, `[{"ID":1,"name":"dhis2"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cache := NewCache(srv.Client().Transport, t.TempDir(), DefaultCacheTTLs)
+	m := NewManager(srv.URL, "user", "pw", &http.Client{Transport: cache})
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Stacks(); err != nil {
+			t.Fatalf("Stacks failed: %s", err)
+		}
+	}
+
+	// stacks are cached for a day so only the first request is sent
+	if l := m.Latency(); l < 20*time.Millisecond {
+		t.Errorf("Latency() = %s, want the latency of the sent request of at least 20ms", l)
+	}
+}
+
+func TestCacheDirPerIdentity(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	const url = "https://im.dhis2.org"
+	dir := func(auth Authenticator) string {
+		t.Helper()
+		d, err := CacheDir(url, auth)
+		if err != nil {
+			t.Fatalf("CacheDir failed: %s", err)
+		}
+		return d
+	}
+
+	if dir(BasicAuth{User: "a", Password: "1"}) != dir(BasicAuth{User: "a", Password: "2"}) {
+		t.Error("a user has different caches depending on the password")
+	}
+	dirs := map[string]Authenticator{}
+	for _, auth := range []Authenticator{
+		BasicAuth{User: "a"},
+		BasicAuth{User: "b"},
+		BearerToken{Token: "eyJhbGci1"},
+		BearerToken{Token: "eyJhbGci2"},
+		NewRefreshToken("eyJhbGci1"),
+	} {
+		d := dir(auth)
+		if other, ok := dirs[d]; ok {
+			t.Errorf("%#v shares the cache with %#v", auth, other)
+		}
+		dirs[d] = auth
+	}
+}
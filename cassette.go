@@ -0,0 +1,215 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Cassette holds requests sent to the instance manager and the responses to
+// them. Credentials are redacted so that cassettes can be attached to bug
+// reports.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is a http.RoundTripper recording requests and responses into a
+// cassette which is written to a file on Close.
+type Recorder struct {
+	transport http.RoundTripper
+	path      string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a recorder sending requests via transport and saving
+// them to the file at path. The http.DefaultTransport is used if transport is
+// nil.
+func NewRecorder(transport http.RoundTripper, path string) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport, path: path}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		reqBody = b
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// the body of a switched protocol is a connection and cannot be recorded
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	u := *req.URL
+	u.User = nil
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    u.String(),
+			Header: redactHeaders(req.Header),
+			Body:   string(redactBody(reqBody)),
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeaders(resp.Header),
+			Body:       string(redactBody(respBody)),
+		},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Close writes the recorded cassette to the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, b, 0o600)
+}
+
+func redactHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	r := make(http.Header, len(h))
+	for name, vs := range h {
+		for _, v := range vs {
+			r.Add(name, redactHeader(name, v))
+		}
+	}
+	return r
+}
+
+// Replayer is a http.RoundTripper responding with the responses of a
+// cassette instead of sending requests. Requests are matched by method, path
+// and query. Responses to the same request are replayed in the recorded
+// order, the last one is repeated once all have been replayed.
+type Replayer struct {
+	cassette Cassette
+
+	mu sync.Mutex
+	// next is the index of the interaction replayed next per request.
+	next map[string]int
+}
+
+// NewReplayer returns a replayer of the cassette in the file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replayer{next: make(map[string]int)}
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// URL returns the URL of the instance manager the cassette was recorded
+// against.
+func (r *Replayer) URL() string {
+	for _, in := range r.cassette.Interactions {
+		u, err := url.Parse(in.Request.URL)
+		if err == nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host
+		}
+	}
+	return ""
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := requestKey(req.Method, req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, in := range r.cassette.Interactions {
+		u, err := url.Parse(in.Request.URL)
+		if err == nil && requestKey(in.Request.Method, u) == key {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette holds no response to %s", key)
+	}
+	i := r.next[key]
+	if i < len(matches)-1 {
+		r.next[key] = i + 1
+	}
+
+	rec := matches[i].Response
+	header := rec.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+func requestKey(method string, u *url.URL) string {
+	key := method + " " + u.EscapedPath()
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,84 @@
+package instance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tokens" {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+			return
+		}
+		calls++
+		fmt.Fprintf(w, `{"ID":1,"Email":"user%d@dhis2.org"}`, calls)
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec := NewRecorder(srv.Client().Transport, path)
+	m := NewManager(srv.URL, "user", "secret-pw", &http.Client{Transport: rec})
+	if err := m.Login(); err != nil {
+		t.Fatalf("Login failed: %s", err)
+	}
+	var want []string
+	for i := 0; i < 2; i++ {
+		u, err := m.Me()
+		if err != nil {
+			t.Fatalf("Me failed: %s", err)
+		}
+		want = append(want, u.Email)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+	srv.Close()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"eyJhbGci", "dXNlcjpzZWNyZXQtcHc="} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+
+	rep, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("NewReplayer failed: %s", err)
+	}
+	if rep.URL() != srv.URL {
+		t.Errorf("URL() = %q, want %q", rep.URL(), srv.URL)
+	}
+	m = NewManagerWithAuth(rep.URL(), "", BasicAuth{}, &http.Client{Transport: rep})
+	if err := m.Login(); err != nil {
+		t.Fatalf("Login failed on replay: %s", err)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		u, err := m.Me()
+		if err != nil {
+			t.Fatalf("Me failed on replay: %s", err)
+		}
+		got = append(got, u.Email)
+	}
+	// the last response is repeated
+	want = append(want, want[len(want)-1])
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Replayed users mismatch (-want +got): %s\n", diff)
+	}
+
+	if _, err := m.Stacks(); err == nil {
+		t.Error("Stacks() succeeded on replay without a recorded response, want error")
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func clearCache(_ *instance.Manager, _ *instance.Config, args []string, out io.Writer) error {
+	if err := instance.ClearCache(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "cleared the cache")
+	return nil
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+// completeCommand is the hidden command called by the completion scripts.
+// It is passed the words of the command line and prints the candidates for
+// the last word one per line.
+const completeCommand = "__complete"
+
+const bashCompletion = `_{{.Name}}_complete() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _{{.Name}}_complete {{.Name}}
+`
+
+const zshCompletion = `#compdef {{.Name}}
+_{{.Name}}() {
+	local -a candidates
+	candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+	compadd -- $candidates
+}
+compdef _{{.Name}} {{.Name}}
+`
+
+const fishCompletion = `complete -c {{.Name}} -f -a '({{.Name}} __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`
+
+func printCompletion(script string) runFunc {
+	return func(_ *instance.Manager, _ *instance.Config, _ []string, out io.Writer) error {
+		name := filepath.Base(os.Args[0])
+		_, err := io.WriteString(out, strings.ReplaceAll(script, "{{.Name}}", name))
+		return err
+	}
+}
+
+// complete prints the candidates completing the last of the words.
+func complete(words []string, out io.Writer) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+
+	// parse the global flags and find the command
+	globalFlags, flags := newGlobalFlagSet("", io.Discard)
+	var positional []string
+	for i := 0; i < len(prev); i++ {
+		w := prev[i]
+		if len(positional) < 2 && strings.HasPrefix(w, "-") {
+			name, value, ok := strings.Cut(strings.TrimLeft(w, "-"), "=")
+			f := globalFlags.Lookup(name)
+			if f == nil {
+				continue
+			}
+			if !ok && isBoolFlag(f) {
+				value = "true"
+			} else if !ok && i+1 < len(prev) {
+				i++
+				value = prev[i]
+			}
+			_ = globalFlags.Set(name, value)
+			continue
+		}
+		positional = append(positional, w)
+	}
+	c := &completer{flags: *flags, url: flags.URL, user: flags.User}
+	if cfg, err := instance.LoadConfig(flags.Config); err == nil {
+		c.cfg = cfg
+		if c.url == "" {
+			c.url = cfg.URL
+		}
+		if c.user == "" {
+			c.user = cfg.User
+		}
+	}
+
+	var candidates []string
+	switch {
+	case len(positional) == 0 && strings.HasPrefix(cur, "-"):
+		candidates = flagNames(globalFlags)
+	case len(positional) == 0:
+		for _, cmd := range commands {
+			candidates = append(candidates, cmd.resource)
+		}
+	case len(positional) == 1:
+		for _, cmd := range commands {
+			if cmd.resource == positional[0] {
+				candidates = append(candidates, cmd.name)
+			}
+		}
+	default:
+		cmd, args, err := findCommand(positional)
+		if err != nil {
+			return nil
+		}
+		candidates = c.completeArgs(cmd, args, cur)
+	}
+
+	for _, cand := range unique(candidates) {
+		if strings.HasPrefix(cand, cur) {
+			fmt.Fprintln(out, cand)
+		}
+	}
+	return nil
+}
+
+// flagSet returns the flag set of the command. It is empty if the command
+// takes no flags.
+func (c command) flagSet() *flag.FlagSet {
+	fs := newFlagSet(c.resource+" "+c.name, io.Discard)
+	if c.flags != nil {
+		c.flags(fs)
+	}
+	return fs
+}
+
+// flagNames returns the names of the flags in fs like -yes.
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+// isBoolFlag reports whether the flag is given without a value like -yes.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (c *completer) completeArgs(cmd command, args []string, cur string) []string {
+	fs := cmd.flagSet()
+
+	// complete the value of a flag
+	if len(args) > 0 {
+		if last := args[len(args)-1]; strings.HasPrefix(last, "-") {
+			if f := fs.Lookup(strings.TrimLeft(last, "-")); f != nil && !isBoolFlag(f) {
+				return c.completeFlag(cmd, "-"+f.Name, args, cur)
+			}
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return flagNames(fs)
+	}
+
+	switch cmd.complete {
+	case stackArg:
+		return c.values("stacks", func(im *instance.Manager) ([]string, error) {
+			sts, err := im.Stacks()
+			var names []string
+			for _, st := range sts {
+				names = append(names, st.Name)
+			}
+			return names, err
+		})
+	case instanceArg:
+		return c.values("instances", func(im *instance.Manager) ([]string, error) {
+			ins, err := im.Instances()
+			var names []string
+			for _, in := range ins {
+				names = append(names, in.Name)
+			}
+			return names, err
+		})
+	case deploymentArg:
+		return c.values("deployments", func(im *instance.Manager) ([]string, error) {
+			ds, err := im.Deployments()
+			var names []string
+			for _, d := range ds {
+				names = append(names, d.Name)
+			}
+			return names, err
+		})
+	case groupArg:
+		return c.groups()
+	}
+	return nil
+}
+
+// groups returns the names of the groups the user is a member of.
+func (c *completer) groups() []string {
+	return c.values("groups", func(im *instance.Manager) ([]string, error) {
+		u, err := im.Me()
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, g := range u.Groups {
+			names = append(names, g.Name)
+		}
+		return names, nil
+	})
+}
+
+func (c *completer) completeFlag(cmd command, flag string, args []string, cur string) []string {
+	switch flag {
+	case "-stack":
+		return c.completeArgs(command{complete: stackArg}, nil, cur)
+	case "-group":
+		return c.groups()
+	case "-preset":
+		stack := flagValue(args, "-stack")
+		if stack == "" {
+			stack = "dhis2"
+		}
+		if c.cfg == nil {
+			return nil
+		}
+		return c.cfg.Presets.Names(stack)
+	case "-param":
+		stack := flagValue(args, "-stack")
+		if stack == "" {
+			stack = "dhis2"
+		}
+		return c.params(stack)
+	case "-db-param":
+		return c.params(instance.DBStack)
+	case "-core-param":
+		return c.params(instance.CoreStack)
+	case "-selector":
+		// complete the last term of the selector
+		i := strings.LastIndex(cur, ",")
+		prefix := cur[:i+1]
+		candidates := []string{prefix + "name=", prefix + "group=", prefix + "status=" + instance.StatusRunning}
+		for _, g := range c.groups() {
+			candidates = append(candidates, prefix+"group="+g)
+		}
+		return candidates
+	}
+	return nil
+}
+
+// params returns the parameter names of the stack as NAME= to be completed
+// with a value.
+func (c *completer) params(stack string) []string {
+	names := c.values("params-"+stack, func(im *instance.Manager) ([]string, error) {
+		st, err := findStack(im, stack)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, p := range st.RequiredParams {
+			names = append(names, p.Name)
+		}
+		for _, p := range st.OptionalParams {
+			names = append(names, p.Name)
+		}
+		return names, nil
+	})
+	for i, n := range names {
+		names[i] = n + "="
+	}
+	return names
+}
+
+// flagValue returns the value of the last occurrence of the flag in args.
+func flagValue(args []string, flag string) string {
+	var v string
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			v = args[i+1]
+		} else if strings.HasPrefix(a, flag+"=") {
+			v = strings.TrimPrefix(a, flag+"=")
+		}
+	}
+	return v
+}
+
+func unique(ss []string) []string {
+	seen := make(map[string]bool)
+	var u []string
+	for _, s := range ss {
+		if !seen[s] {
+			seen[s] = true
+			u = append(u, s)
+		}
+	}
+	sort.Strings(u)
+	return u
+}
+
+// completionTTL is the duration resource names are cached for completion.
+const completionTTL = 30 * time.Second
+
+// completionTimeout limits the time of requests fetching resource names.
+const completionTimeout = 5 * time.Second
+
+// completer fetches resource names through the manager. The names are cached
+// briefly as completion is triggered repeatedly while typing.
+type completer struct {
+	flags     instance.ManagerFlags
+	url, user string
+	cfg       *instance.Config
+	im        *instance.Manager
+	failed    bool
+}
+
+type completionCache struct {
+	Fetched time.Time `json:"fetched"`
+	Values  []string  `json:"values"`
+}
+
+// values returns the cached values of given kind or fetches them if they are
+// not cached or outdated.
+func (c *completer) values(kind string, fetch func(im *instance.Manager) ([]string, error)) []string {
+	if c.url == "" && c.flags.Replay == "" {
+		return nil
+	}
+
+	file := c.cacheFile(kind)
+	if b, err := os.ReadFile(file); err == nil {
+		var cache completionCache
+		if json.Unmarshal(b, &cache) == nil && time.Since(cache.Fetched) < completionTTL {
+			return cache.Values
+		}
+	}
+
+	im := c.manager()
+	if im == nil {
+		return nil
+	}
+	values, err := fetch(im)
+	if err != nil {
+		return nil
+	}
+	if b, err := json.Marshal(completionCache{Fetched: time.Now(), Values: values}); err == nil {
+		if err := os.MkdirAll(filepath.Dir(file), 0o700); err == nil {
+			_ = os.WriteFile(file, b, 0o600)
+		}
+	}
+	return values
+}
+
+// manager returns a manager that is logged in or nil if login failed. The
+// user cannot be prompted for the password while completing and should not
+// wait long for candidates.
+func (c *completer) manager() *instance.Manager {
+	if c.im != nil || c.failed {
+		return c.im
+	}
+	f := c.flags
+	f.NoPrompt = true
+	f.Timeout = completionTimeout
+	f.SavePassword = false
+	f.Record = ""
+	if f.PasswordFile == "-" {
+		// stdin is the terminal the user is completing in
+		f.PasswordFile = ""
+	}
+	im, _, closeManager, err := instance.NewManagerFromFlags(f)
+	_ = closeManager()
+	if err != nil {
+		c.failed = true
+		return nil
+	}
+	c.im = im
+	return im
+}
+
+// cacheFile returns the file values of given kind are cached in. Caches are
+// kept per instance manager and user.
+func (c *completer) cacheFile(kind string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	h := sha256.Sum256([]byte(c.url + "\x00" + c.user))
+	return filepath.Join(dir, "dhis2-im", "completion", hex.EncodeToString(h[:8]), kind+".json")
+}
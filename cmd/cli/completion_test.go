@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComplete(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"GET /me":        `{"ID":1,"Email":"user@dhis2.org","Groups":[{"ID":2,"Name":"qa"},{"ID":3,"Name":"whoami"}]}`,
+		"GET /stacks/":   `[{"ID":1,"name":"dhis2"},{"ID":2,"name":"whoami-go"}]`,
+		"GET /instances": `[{"Name":"qa","Instances":[{"ID":7,"Name":"dev","GroupName":"qa"},{"ID":8,"Name":"demo","GroupName":"qa"}]}]`,
+	})
+	login := []string{"-url", srv.URL, "-user", "user", "-pw", "pw", "-keyring=none"}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{words: []string{"st"}, want: []string{"stacks"}},
+		{words: []string{"-no-cache", "gr"}, want: []string{"groups"}},
+		{words: []string{"-us"}, want: []string{"-user"}},
+		{words: []string{"instances", "c"}, want: []string{"clone", "create"}},
+		{words: []string{"instances", "delete", "-"}, want: []string{"-older-than", "-parallel", "-selector", "-yes"}},
+		{words: []string{"stacks", "diff", "-"}, want: []string{"-changed"}},
+		{words: append(login, "stacks", "show", ""), want: []string{"dhis2", "whoami-go"}},
+		{words: append(login, "instances", "delete", "-yes", "d"), want: []string{"demo", "dev"}},
+		{words: append(login, "instances", "create", "-group", ""), want: []string{"qa", "whoami"}},
+		{words: append(login, "instances", "create", "-stack", "w"), want: []string{"whoami-go"}},
+		{words: append(login, "instances", "delete", "-selector", "name=dev,g"), want: []string{"name=dev,group=", "name=dev,group=qa", "name=dev,group=whoami"}},
+		// resources are not completed without a url to fetch them from
+		{words: []string{"-user", "user", "-pw", "pw", "stacks", "show", ""}, want: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.words, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := complete(tc.words, &out); err != nil {
+				t.Fatalf("complete failed: %s", err)
+			}
+
+			got := strings.Fields(out.String())
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Candidates mismatch (-want +got): %s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCompleteWithTokenAuth(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"GET /instances": `[{"Name":"qa","Instances":[{"ID":7,"Name":"dev","GroupName":"qa"}]}]`,
+	})
+	t.Setenv("IM_TOKEN", "eyJhbGci")
+	config := `{"url":"` + srv.URL + `","auth":{"method":"token","env":"IM_TOKEN"}}`
+	if err := os.WriteFile(os.Getenv("DHIS2_IM_CONFIG"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := complete([]string{"instances", "ttl", ""}, &out); err != nil {
+		t.Fatalf("complete failed: %s", err)
+	}
+
+	if got, want := out.String(), "dev\n"; got != want {
+		t.Errorf("complete() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func listDeployments(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	ds, err := im.Deployments()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tGROUP\tSTATUS\tCORE ID\tDB ID\tEXPIRES IN")
+	for _, d := range ds {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", d.Name, d.Core.GroupName, d.Status(), d.Core.ID, d.DB.ID, expiresIn(d.Core))
+	}
+	return w.Flush()
+}
+
+func createDeployment(fs *flag.FlagSet) runFunc {
+	group := fs.String("group", "2", "Name or id of the group to deploy to")
+	timeout := fs.Duration("timeout", 10*time.Minute, "Time to wait for the database instance to run")
+	dbParams := paramsFlag{}
+	fs.Var(dbParams, "db-param", "Parameter of the "+instance.DBStack+" stack as NAME=VALUE, can be repeated")
+	coreParams := paramsFlag{}
+	fs.Var(coreParams, "core-param", "Parameter of the "+instance.CoreStack+" stack as NAME=VALUE, can be repeated")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 1 {
+			return errors.New("name of the deployment is required")
+		}
+
+		groupID, err := im.GroupID(*group)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		fmt.Fprintf(out, "creating database instance and waiting for it to run\n")
+		d, err := im.CreateDeployment(ctx, fs.Arg(0), groupID, dbParams, coreParams)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created deployment %q with database instance %d and core instance %d\n", d.Name, d.DB.ID, d.Core.ID)
+		return nil
+	}
+}
+
+func deleteDeployment(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("name of the deployment is required")
+	}
+	d, err := im.DeploymentByName(args[0])
+	if err != nil {
+		return err
+	}
+	if err := im.DeleteDeployment(*d); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "deleted deployment %q\n", d.Name)
+	return nil
+}
+
+func restartDeployment(fs *flag.FlagSet) runFunc {
+	timeout := fs.Duration("timeout", 10*time.Minute, "Time to wait for the database instance to run")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 1 {
+			return errors.New("name of the deployment is required")
+		}
+		d, err := im.DeploymentByName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		if err := im.RestartDeployment(ctx, *d); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "restarted deployment %q\n", d.Name)
+		return nil
+	}
+}
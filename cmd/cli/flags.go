@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// paramsFlag collects parameters given as NAME=VALUE. The flag can be
+// repeated to pass multiple parameters.
+type paramsFlag map[string]string
+
+func (p paramsFlag) String() string {
+	var ps []string
+	for k, v := range p {
+		ps = append(ps, k+"="+v)
+	}
+	sort.Strings(ps)
+	return strings.Join(ps, ",")
+}
+
+func (p paramsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("invalid parameter %q: expected format NAME=VALUE", s)
+	}
+	p[strings.TrimSpace(k)] = v
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func listGroups(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	gs, err := im.Groups()
+	if err != nil {
+		return err
+	}
+	me, err := im.Me()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tHOSTNAME\tMEMBERS\tROLE")
+	for _, g := range gs {
+		members := "-"
+		if g.Users != nil {
+			members = strconv.Itoa(len(g.Users))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", g.ID, g.Name, g.Hostname, members, role(me, g.Name))
+	}
+	return w.Flush()
+}
+
+func showGroup(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("group name is required")
+	}
+	g, err := im.Group(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s (%d)\nhostname: %s\n\n", g.Name, g.ID, g.Hostname)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tROLE")
+	for _, u := range g.Users {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", u.ID, u.Email, role(&u, g.Name))
+	}
+	return w.Flush()
+}
+
+// role returns the role of the user in the group.
+func role(u *instance.User, group string) string {
+	switch {
+	case u.IsAdmin(group):
+		return "admin"
+	case u.IsMember(group):
+		return "member"
+	}
+	return "-"
+}
+
+// findGroup returns the group with given name or ID.
+func findGroup(im *instance.Manager, nameOrID string) (*instance.Group, error) {
+	id, err := strconv.Atoi(nameOrID)
+	if err != nil {
+		return im.Group(nameOrID)
+	}
+	gs, err := im.Groups()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gs {
+		if g.ID == id {
+			return &g, nil
+		}
+	}
+	return nil, fmt.Errorf("group with id %d not found", id)
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func listInstances(fs *flag.FlagSet) runFunc {
+	watch := fs.Bool("watch", false, "Keep watching and print instances that are added, updated or deleted")
+	interval := fs.String("interval", "5s", "Interval in which instances are fetched when watching")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if *watch {
+			d, err := instance.ParseDuration(*interval)
+			if err != nil {
+				return err
+			}
+			if d <= 0 {
+				return fmt.Errorf("interval must be positive, got %q", *interval)
+			}
+			return watchInstances(im, d, out)
+		}
+
+		ins, err := im.Instances()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tGROUP\tSTATUS\tEXPIRES IN")
+		for _, in := range ins {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", in.ID, in.Name, in.GroupName, in.Status, expiresIn(in))
+		}
+		return w.Flush()
+	}
+}
+
+func watchInstances(im *instance.Manager, interval time.Duration, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	events, err := im.Watch(ctx, interval)
+	if err != nil {
+		return err
+	}
+
+	// rows are printed as events happen so columns have a fixed width
+	const row = "%-8s  %-7s  %-6s  %-30s  %-15s  %-10s  %s\n"
+	fmt.Fprintf(out, row, "TIME", "EVENT", "ID", "NAME", "GROUP", "STATUS", "EXPIRES IN")
+	for e := range events {
+		now := time.Now().Format("15:04:05")
+		if e.Err != nil {
+			fmt.Fprintf(out, "%-8s  %-7s  %s\n", now, "error", e.Err)
+			continue
+		}
+		in := e.Instance
+		fmt.Fprintf(out, row, now, e.Type, strconv.Itoa(in.ID), in.Name, in.GroupName, in.Status, expiresIn(in))
+	}
+	return nil
+}
+
+func createInstance(fs *flag.FlagSet) runFunc {
+	group := fs.String("group", "2", "Name or id of the group to create the instance in")
+	stack := fs.String("stack", "dhis2", "Name or id of the stack to deploy")
+	preset := fs.String("preset", "", "Preset of parameters of the stack from the config")
+	params := paramsFlag{}
+	fs.Var(params, "param", "Parameter of the stack as NAME=VALUE overriding the preset, can be repeated")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 1 {
+			return errors.New("name of the instance is required")
+		}
+
+		g, err := findGroup(im, *group)
+		if err != nil {
+			return err
+		}
+		st, err := findStack(im, *stack)
+		if err != nil {
+			return err
+		}
+		if *preset != "" {
+			presetParams, err := cfg.Presets.Params(st.Name, *preset, instance.PresetData{
+				Name:  fs.Arg(0),
+				Stack: st.Name,
+				Group: g.Name,
+				User:  im.User(),
+			})
+			if err != nil {
+				return err
+			}
+			for k, v := range params {
+				presetParams[k] = v
+			}
+			params = presetParams
+		}
+		in, err := im.Create(fs.Arg(0), g.ID, st.ID, params)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created instance %q with id %d\n", in.Name, in.ID)
+		return nil
+	}
+}
+
+func deleteInstances(fs *flag.FlagSet) runFunc {
+	selector := fs.String("selector", "", "Select instances like name=release-*,group=qa,status=Running")
+	olderThan := fs.String("older-than", "", "Select instances created longer ago than the duration like 7d")
+	parallel := fs.Int("parallel", 4, "Number of instances deleted concurrently")
+	yes := fs.Bool("yes", false, "Delete without asking for confirmation")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		sel, err := instance.ParseSelector(*selector)
+		if err != nil {
+			return err
+		}
+		if *olderThan != "" {
+			if sel.OlderThan, err = instance.ParseDuration(*olderThan); err != nil {
+				return err
+			}
+		}
+		if sel.Empty() && fs.NArg() == 0 {
+			return errors.New("names of the instances or a selector is required")
+		}
+
+		var ins []instance.Instance
+		if !sel.Empty() {
+			if ins, err = im.Select(sel); err != nil {
+				return err
+			}
+		}
+		for _, name := range fs.Args() {
+			in, err := im.InstanceByName(name)
+			if err != nil {
+				return err
+			}
+			ins = append(ins, *in)
+		}
+		ins = uniqueInstances(ins)
+		if len(ins) == 0 {
+			fmt.Fprintln(out, "no instances match")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tGROUP\tSTATUS\tCREATED")
+		for _, in := range ins {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s ago\n", in.ID, in.Name, in.GroupName, in.Status, instance.FormatDuration(time.Since(in.CreatedAt)))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if !*yes {
+			ok, err := confirm(out, fmt.Sprintf("Delete %d instances?", len(ins)))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "aborted")
+				return nil
+			}
+		}
+
+		results := im.DeleteAll(ins, *parallel)
+		return printResults(out, "deleted", results)
+	}
+}
+
+// uniqueInstances returns the instances without the ones occurring again
+// like an instance that is named and matched by the selector.
+func uniqueInstances(ins []instance.Instance) []instance.Instance {
+	seen := make(map[int]bool)
+	var u []instance.Instance
+	for _, in := range ins {
+		if !seen[in.ID] {
+			seen[in.ID] = true
+			u = append(u, in)
+		}
+	}
+	return u
+}
+
+// printResults prints the result of a bulk operation per instance. An error
+// is returned if the operation failed for any instance.
+func printResults(out io.Writer, done string, results []instance.BulkResult) error {
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(out, "FAILED %s: %s\n", r.Instance.Name, r.Err)
+			continue
+		}
+		fmt.Fprintf(out, "%s %s\n", done, r.Instance.Name)
+	}
+	fmt.Fprintf(out, "%s %d of %d instances\n", done, len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("failed for %d instances", failed)
+	}
+	return nil
+}
+
+func cloneInstance(fs *flag.FlagSet) runFunc {
+	group := fs.String("group", "", "Name or id of the group to create the clone in (default: group of the instance)")
+	overrides := paramsFlag{}
+	fs.Var(overrides, "param", "Parameter overriding the one of the instance as NAME=VALUE, can be repeated")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 2 {
+			return errors.New("name of the instance and name of the clone are required")
+		}
+
+		var groupID int
+		if *group != "" {
+			var err error
+			if groupID, err = im.GroupID(*group); err != nil {
+				return err
+			}
+		}
+		src, err := im.InstanceByName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		in, err := im.CloneToGroup(src.ID, fs.Arg(1), groupID, overrides)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "cloned instance %q into %q with id %d\n", src.Name, in.Name, in.ID)
+		return nil
+	}
+}
+
+func setParams(fs *flag.FlagSet) runFunc {
+	yes := fs.Bool("yes", false, "Redeploy without asking for confirmation")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() < 2 {
+			return errors.New("name of the instance and at least one NAME=VALUE are required")
+		}
+		params := paramsFlag{}
+		for _, p := range fs.Args()[1:] {
+			if err := params.Set(p); err != nil {
+				return err
+			}
+		}
+
+		in, err := im.InstanceByName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		changes := instance.DiffParams(*in, params)
+		if len(changes) == 0 {
+			fmt.Fprintln(out, "parameters are unchanged")
+			return nil
+		}
+		printParamChanges(out, changes)
+		if !*yes {
+			ok, err := confirm(out, fmt.Sprintf("Redeploy instance %q?", in.Name))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(out, "aborted")
+				return nil
+			}
+		}
+
+		in, err = im.UpdateParameters(in.ID, params)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "updated instance %q, status is %s\n", in.Name, in.Status)
+		return nil
+	}
+}
+
+func printParamChanges(out io.Writer, changes []instance.ParamChange) {
+	for _, c := range changes {
+		if c.Added {
+			fmt.Fprintln(out, instance.AddedText.Render(fmt.Sprintf("+ %s=%s", c.Name, c.New)))
+			continue
+		}
+		fmt.Fprintln(out, instance.RemovedText.Render(fmt.Sprintf("- %s=%s", c.Name, c.Old)))
+		fmt.Fprintln(out, instance.AddedText.Render(fmt.Sprintf("+ %s=%s", c.Name, c.New)))
+	}
+}
+
+func showTTL(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("name of the instance is required")
+	}
+	in, err := im.InstanceByName(args[0])
+	if err != nil {
+		return err
+	}
+
+	ttl := in.TTL()
+	if ttl == 0 {
+		fmt.Fprintf(out, "instance %q does not expire\n", in.Name)
+		return nil
+	}
+	fmt.Fprintf(out, "instance %q has a TTL of %s and expires in %s\n", in.Name, instance.FormatDuration(ttl), expiresIn(*in))
+	return nil
+}
+
+func extendTTL(fs *flag.FlagSet) runFunc {
+	by := fs.String("by", "", "Duration to extend the TTL by like 24h or 7d")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 1 {
+			return errors.New("name of the instance is required")
+		}
+		d, err := instance.ParseDuration(*by)
+		if err != nil {
+			return err
+		}
+
+		in, err := im.InstanceByName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		exp, err := im.ExtendTTL(in.ID, d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "instance %q now expires in %s at %s\n", in.Name, instance.FormatDuration(time.Until(exp)), exp.Local().Format(time.RFC1123))
+		return nil
+	}
+}
+
+// expiresIn returns the time left until the instance expires.
+func expiresIn(in instance.Instance) string {
+	exp := in.Expiry()
+	if exp.IsZero() {
+		return "never"
+	}
+	left := time.Until(exp)
+	if left <= 0 {
+		return "expired"
+	}
+	return instance.FormatDuration(left)
+}
+
+func printURL(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	u, err := instanceURL(im, args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, u)
+	return nil
+}
+
+func openInstance(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	u, err := instanceURL(im, args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "opening %s\n", u)
+	return instance.OpenBrowser(u)
+}
+
+func instanceURL(im *instance.Manager, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("name of the instance is required")
+	}
+	in, err := im.InstanceByName(args[0])
+	if err != nil {
+		return "", err
+	}
+	return im.InstanceURL(in)
+}
+
+func portForward(fs *flag.FlagSet) runFunc {
+	service := fs.String("service", instance.DBService, "Service of the instance to forward to")
+	local := fs.Int("local", 0, "Local port to listen on (default: same as the remote port)")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 2 {
+			return errors.New("name of the instance and remote port are required")
+		}
+		port, err := strconv.Atoi(fs.Arg(1))
+		if err != nil {
+			return fmt.Errorf("invalid remote port %q: %s", fs.Arg(1), err)
+		}
+		if *local == 0 {
+			*local = port
+		}
+
+		in, err := im.InstanceByName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(*local)))
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Fprintf(out, "forwarding %s to %s:%d of instance %q, press ctrl+c to stop\n", l.Addr(), *service, port, in.Name)
+		return im.PortForward(ctx, in.ID, *service, port, l)
+	}
+}
+
+func dbShell(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("name of the instance is required")
+	}
+	psql, err := exec.LookPath("psql")
+	if err != nil {
+		return fmt.Errorf("psql is required: %s", err)
+	}
+
+	in, err := im.InstanceByName(args[0])
+	if err != nil {
+		return err
+	}
+	c, err := im.DBConnection(in.ID)
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- im.PortForward(ctx, in.ID, instance.DBService, instance.DBPort, l)
+	}()
+	addr := l.Addr().(*net.TCPAddr)
+	c.Host, c.Port = addr.IP.String(), addr.Port
+
+	cmd := exec.Command(psql, "-h", c.Host, "-p", strconv.Itoa(c.Port), "-U", c.Username, c.Database)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+c.Password)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// psql handles ctrl+c itself
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	err = cmd.Run()
+
+	cancel()
+	if ferr := <-errc; ferr != nil && err == nil {
+		err = ferr
+	}
+	return err
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"strings"
 
 	instance "github.com/teleivo/dhis2-im-manager-cli"
 )
@@ -18,50 +19,170 @@ func main() {
 	}
 }
 
-func run(args []string, out io.Writer) error {
-	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
-	url := fs.String("url", "", "Instance manager URL")
-	user := fs.String("user", "", "User to login and perform actions on the instance manager")
-	pw := fs.String("pw", "", "Password of user")
-	name := fs.String("name", "", "Name of the instance to be created/deployed")
-	// TODO allow passing in the name of the group
-	group := fs.Int("group", 2, "Group to perform actions on the instance manager")
-	// TODO allow passing in the name of the stack
-	stack := fs.Int("stack", 1, "Group to perform actions on the instance manager")
-	err := fs.Parse(args[1:])
-	if err != nil {
-		return err
-	}
-	if *url == "" || *user == "" || *pw == "" || *name == "" {
-		return errors.New("url, user, pw and name are required")
+func run(args []string, out io.Writer) (err error) {
+	if len(args) > 1 && args[1] == completeCommand {
+		return complete(args[2:], out)
 	}
 
-	// TODO set some timeouts
-	client := &http.Client{}
-	im := instance.NewManager(*url, *user, *pw, client)
-	err = im.Login()
+	fs, flags := newGlobalFlagSet(args[0], out)
+	flags.Log = os.Stderr
+	fs.Usage = func() {
+		fmt.Fprintf(out, "Usage: %s [flags] <command> [args]\n\nFlags:\n", args[0])
+		fs.PrintDefaults()
+		fmt.Fprintf(out, "\nCommands:\n")
+		printCommands(out)
+	}
+	err = fs.Parse(args[1:])
 	if err != nil {
 		return err
 	}
-	_ = group
-	// err = im.Create(*name, *group, *stack)
-	// if err != nil {
-	// 	return err
-	// }
-	fmt.Println("stacks")
-	sts, err := im.Stacks()
+
+	instance.ConfigureColors()
+
+	cmd, cmdArgs, err := findCommand(fs.Args())
 	if err != nil {
+		fs.Usage()
 		return err
 	}
-	fmt.Printf("stacks: %+v\n", sts)
+	run := cmd.run
+	if cmd.flags != nil {
+		cfs := newFlagSet(cmd.resource+" "+cmd.name, out)
+		run = cmd.flags(cfs)
+		if err := cfs.Parse(cmdArgs); err != nil {
+			return err
+		}
+		cmdArgs = cfs.Args()
+	}
+	if cmd.noLogin {
+		return run(nil, nil, cmdArgs, out)
+	}
 
-	fmt.Println("stack", *stack)
-	st, err := im.Stack(*stack)
+	im, cfg, closeManager, err := instance.NewManagerFromFlags(*flags)
+	defer func() {
+		if cerr := closeManager(); err == nil {
+			err = cerr
+		}
+	}()
 	if err != nil {
 		return err
 	}
-	fmt.Printf("stack: %+v\n", st)
 
-	_ = out
-	return nil
+	return run(im, cfg, cmdArgs, out)
+}
+
+// newGlobalFlagSet creates the flag set of the flags preceding the command.
+func newGlobalFlagSet(name string, out io.Writer) (*flag.FlagSet, *instance.ManagerFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	flags := &instance.ManagerFlags{}
+	flags.Register(fs, "stderr")
+	return fs, flags
+}
+
+// runFunc runs a command with its positional arguments.
+type runFunc func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error
+
+// command is a subcommand like "instances list" operating on a resource.
+type command struct {
+	resource string
+	name     string
+	args     string
+	help     string
+	run      runFunc
+	// flags defines the flags of commands taking some in the flag set and
+	// returns the func running the command once the flags are parsed.
+	flags func(fs *flag.FlagSet) runFunc
+	// complete is the kind of positional arguments the command takes.
+	complete string
+	// noLogin commands are run without a manager.
+	noLogin bool
+}
+
+func (c command) usage() string {
+	return strings.TrimSpace(c.resource + " " + c.name + " " + c.args)
+}
+
+// Kinds of arguments a command takes which are completed with the names of
+// the resources.
+const (
+	stackArg      = "stack"
+	instanceArg   = "instance"
+	deploymentArg = "deployment"
+	groupArg      = "group"
+)
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{resource: "stacks", name: "list", help: "List all stacks and how many instances of your groups use them", run: listStacks},
+		{resource: "stacks", name: "show", args: "<name|id>", help: "Show a stack and its parameters", run: showStack, complete: stackArg},
+		{resource: "stacks", name: "diff", args: "[-changed] <name|id> <name|id>", help: "Compare the parameters of two stacks", flags: diffStacks, complete: stackArg},
+		{resource: "instances", name: "list", args: "[-watch] [-interval duration]", help: "List instances of all your groups", flags: listInstances},
+		{resource: "instances", name: "create", args: "[-group name|id] [-stack name|id] [-preset name] [-param NAME=VALUE]... <name>", help: "Create an instance", flags: createInstance},
+		{resource: "instances", name: "delete", args: "[-selector key=value,...] [-older-than duration] [-parallel n] [-yes] [name]...", help: "Delete instances by name or selector", flags: deleteInstances, complete: instanceArg},
+		{resource: "instances", name: "clone", args: "[-group name|id] [-param NAME=VALUE]... <name> <new-name>", help: "Create an instance with the stack and parameters of another", flags: cloneInstance, complete: instanceArg},
+		{resource: "instances", name: "set-param", args: "[-yes] <name> <NAME=VALUE>...", help: "Change parameters of an instance and redeploy it", flags: setParams, complete: instanceArg},
+		{resource: "instances", name: "url", args: "<name>", help: "Print the public URL of an instance", run: printURL, complete: instanceArg},
+		{resource: "instances", name: "open", args: "<name>", help: "Open an instance in the browser", run: openInstance, complete: instanceArg},
+		{resource: "instances", name: "port-forward", args: "[-service name] [-local port] <name> <port>", help: "Forward a local port to a service of an instance", flags: portForward, complete: instanceArg},
+		{resource: "instances", name: "db-shell", args: "<name>", help: "Open a psql shell to the database of an instance", run: dbShell, complete: instanceArg},
+		{resource: "instances", name: "ttl", args: "<name>", help: "Show the time to live of an instance", run: showTTL, complete: instanceArg},
+		{resource: "instances", name: "extend", args: "-by <duration> <name>", help: "Extend the time to live of an instance", flags: extendTTL, complete: instanceArg},
+		{resource: "groups", name: "list", help: "List groups and your membership in them", run: listGroups},
+		{resource: "groups", name: "show", args: "<name>", help: "Show a group and its members", run: showGroup, complete: groupArg},
+		{resource: "groups", name: "add-user", args: "<group> <email|id>", help: "Add a user to a group (group admins only, only administrators can pass the email)", run: changeGroup((*instance.Manager).AddUserToGroup, "added %s to group %s"), complete: groupArg},
+		{resource: "groups", name: "remove-user", args: "<group> <email|id>", help: "Remove a user from a group (group admins only)", run: changeGroup((*instance.Manager).RemoveUserFromGroup, "removed %s from group %s"), complete: groupArg},
+		{resource: "groups", name: "grant-admin", args: "<group> <email|id>", help: "Make a user an admin of a group (group admins only)", run: changeGroup((*instance.Manager).GrantGroupAdmin, "made %s an admin of group %s"), complete: groupArg},
+		{resource: "users", name: "list", help: "List all users (administrators only)", run: listUsers},
+		{resource: "users", name: "create", args: "[-pw-file path] <email>", help: "Create a user (administrators only)", flags: createUser},
+		{resource: "deployments", name: "list", help: "List deployments of DHIS2 linked to their database", run: listDeployments},
+		{resource: "deployments", name: "create", args: "[-group name|id] [-timeout duration] [-db-param NAME=VALUE]... [-core-param NAME=VALUE]... <name>", help: "Create a database and a DHIS2 instance wired to it", flags: createDeployment},
+		{resource: "deployments", name: "delete", args: "<name>", help: "Delete the DHIS2 and database instance of a deployment", run: deleteDeployment, complete: deploymentArg},
+		{resource: "deployments", name: "restart", args: "[-timeout duration] <name>", help: "Restart the database and then the DHIS2 instance of a deployment", flags: restartDeployment, complete: deploymentArg},
+		{resource: "cache", name: "clear", help: "Remove all cached responses of instance managers", run: clearCache, noLogin: true},
+		{resource: "completion", name: "bash", help: "Print the bash completion script", run: printCompletion(bashCompletion), noLogin: true},
+		{resource: "completion", name: "zsh", help: "Print the zsh completion script", run: printCompletion(zshCompletion), noLogin: true},
+		{resource: "completion", name: "fish", help: "Print the fish completion script", run: printCompletion(fishCompletion), noLogin: true},
+	}
+}
+
+func findCommand(args []string) (command, []string, error) {
+	if len(args) < 2 {
+		return command{}, nil, errors.New("command is required")
+	}
+	for _, c := range commands {
+		if c.resource == args[0] && c.name == args[1] {
+			return c, args[2:], nil
+		}
+	}
+	return command{}, nil, fmt.Errorf("unknown command %q", strings.Join(args[:2], " "))
+}
+
+func printCommands(out io.Writer) {
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %s\n    \t%s\n", c.usage(), c.help)
+	}
+}
+
+// stdin is read from when asking the user for confirmation.
+var stdin io.Reader = os.Stdin
+
+// confirm asks the user the question and reports whether the user answered
+// with yes.
+func confirm(out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// newFlagSet creates the flag set of the command.
+func newFlagSet(c string, out io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(c, flag.ContinueOnError)
+	fs.SetOutput(out)
+	return fs
 }
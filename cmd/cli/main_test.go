@@ -1,13 +1,227 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 )
 
-func TestConcat(t *testing.T) {
-	if diff := cmp.Diff("ab", concat("a", "b")); diff != "" {
-		t.Fatalf("concat(%s, %s) mismatch (-want +got): %s\n", "a", "b", diff)
+// newTestServer returns an instance manager responding to requests with the
+// body of their method and path.
+func newTestServer(t *testing.T, bodies map[string]string) *httptest.Server {
+	t.Helper()
+	return newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	})
+}
+
+// newTestHandlerServer returns an instance manager issuing tokens and passing
+// all other requests to the handler. The config and cache of the user are
+// kept in a temporary directory.
+func newTestHandlerServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("DHIS2_IM_CONFIG", filepath.Join(dir, "config.json"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tokens" {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// runCommand runs the cli with the command line logged in to the server.
+func runCommand(t *testing.T, srv *httptest.Server, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	args = append([]string{"cli", "-url", srv.URL, "-user", "user", "-pw", "pw", "-keyring", "none", "-no-cache"}, args...)
+	err := run(args, &out)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"GET /stacks/":  `[{"ID":1,"name":"dhis2"},{"ID":2,"name":"whoami-go"}]`,
+		"GET /stacks/1": `{"ID":1,"name":"dhis2","requiredParameters":[{"Name":"DATABASE_ID"}],"optionalParameters":[{"Name":"IMAGE_TAG","DefaultValue":"2.39"}]}`,
+		"GET /instances": `[{"Name":"qa","Instances":[
+			{"ID":7,"Name":"dev","GroupName":"qa","StackID":1,"Status":"Running"},
+			{"ID":8,"Name":"demo","GroupName":"qa","StackID":1,"Status":"Booting"}
+		]}]`,
+	})
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			args: []string{"stacks", "list"},
+			want: []string{
+				"ID  NAME       INSTANCES  CREATED  UPDATED",
+				"1   dhis2      2          -        -",
+				"2   whoami-go  0          -        -",
+			},
+		},
+		{
+			args: []string{"stacks", "show", "1"},
+			want: []string{
+				"dhis2 (1)",
+				"created: -",
+				"updated: -",
+				"instances: 2",
+				"",
+				"PARAMETER    REQUIRED  DEFAULT",
+				"DATABASE_ID  yes",
+				"IMAGE_TAG    no        2.39",
+			},
+		},
+		{
+			args: []string{"instances", "list"},
+			want: []string{
+				"ID  NAME  GROUP  STATUS   EXPIRES IN",
+				"7   dev   qa     Running  never",
+				"8   demo  qa     Booting  never",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			out, err := runCommand(t, srv, tc.args...)
+			if err != nil {
+				t.Fatalf("run failed: %s", err)
+			}
+
+			got := strings.Split(strings.TrimRight(out, "\n"), "\n")
+			for i := range got {
+				got[i] = strings.TrimRight(got[i], " ")
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Output mismatch (-want +got): %s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRunFails(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"GET /instances": `[]`,
+	})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"stacks"}, want: "command is required"},
+		{args: []string{"stacks", "remove"}, want: `unknown command "stacks remove"`},
+		{args: []string{"stacks", "show"}, want: "stack name or id is required"},
+		{args: []string{"instances", "extend", "-by", "1d"}, want: "name of the instance is required"},
+		{args: []string{"instances", "ttl", "dev"}, want: `instance "dev" not found`},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			_, err := runCommand(t, srv, tc.args...)
+
+			if err == nil || err.Error() != tc.want {
+				t.Errorf("run failed with %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateInstanceWithPreset(t *testing.T) {
+	var created map[string]interface{}
+	srv := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /groups":
+			fmt.Fprint(w, `[{"ID":2,"Name":"qa"},{"ID":3,"Name":"whoami"}]`)
+		case "GET /stacks/":
+			fmt.Fprint(w, `[{"ID":1,"name":"dhis2"}]`)
+		case "GET /stacks/1":
+			fmt.Fprint(w, `{"ID":1,"name":"dhis2","optionalParameters":[{"Name":"DATABASE_ID"}]}`)
+		case "POST /instances":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Errorf("decoding created instance failed: %s", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"ID":9,"Name":"dev"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	config := `{"presets":{"dhis2":{"group":{"DATABASE_ID":"{{.Group}}-{{.Name}}"}}}}`
+	if err := os.WriteFile(os.Getenv("DHIS2_IM_CONFIG"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, srv, "instances", "create", "-group", "2", "-preset", "group", "dev")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	if want := "created instance \"dev\" with id 9\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	want := map[string]interface{}{
+		"name":    "dev",
+		"groupId": float64(2),
+		"stackID": float64(1),
+		"optionalParameters": []interface{}{
+			map[string]interface{}{"Name": "DATABASE_ID", "Value": "qa-dev"},
+		},
+	}
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Errorf("Created instance mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestDeleteInstancesOnce(t *testing.T) {
+	var mu sync.Mutex
+	deleted := make(map[string]int)
+	srv := newTestHandlerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/instances":
+			fmt.Fprint(w, `[{"Name":"qa","Instances":[{"ID":7,"Name":"dev","GroupName":"qa"},{"ID":8,"Name":"demo","GroupName":"qa"}]}]`)
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			deleted[r.URL.Path]++
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	out, err := runCommand(t, srv, "instances", "delete", "-yes", "-selector", "name=d*", "dev")
+	if err != nil {
+		t.Fatalf("run failed: %s\n%s", err, out)
+	}
+
+	want := map[string]int{"/instances/7": 1, "/instances/8": 1}
+	if diff := cmp.Diff(want, deleted); diff != "" {
+		t.Errorf("Deleted instances mismatch (-want +got): %s\n", diff)
+	}
+	if !strings.Contains(out, "deleted 2 of 2 instances") {
+		t.Errorf("output does not report 2 deleted instances:\n%s", out)
 	}
 }
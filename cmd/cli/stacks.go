@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func listStacks(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	sts, err := im.Stacks()
+	if err != nil {
+		return err
+	}
+	// only instances of the groups of the user are counted
+	ins, err := im.StackInstances()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tINSTANCES\tCREATED\tUPDATED")
+	for _, st := range sts {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\n", st.ID, st.Name, len(ins[st.ID]), instance.FormatTime(st.CreatedAt), instance.FormatTime(st.UpdatedAt))
+	}
+	return w.Flush()
+}
+
+func showStack(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("stack name or id is required")
+	}
+	st, err := findStack(im, args[0])
+	if err != nil {
+		return err
+	}
+	ins, err := im.StackInstances()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s (%d)\ncreated: %s\nupdated: %s\ninstances: %d\n\n", st.Name, st.ID, instance.FormatTime(st.CreatedAt), instance.FormatTime(st.UpdatedAt), len(ins[st.ID]))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARAMETER\tREQUIRED\tDEFAULT")
+	for _, p := range st.RequiredParams {
+		fmt.Fprintf(w, "%s\tyes\t\n", p.Name)
+	}
+	for _, p := range st.OptionalParams {
+		fmt.Fprintf(w, "%s\tno\t%s\n", p.Name, p.DefaultValue)
+	}
+	return w.Flush()
+}
+
+func diffStacks(fs *flag.FlagSet) runFunc {
+	changed := fs.Bool("changed", false, "Only show parameters that differ")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 2 {
+			return errors.New("names or ids of two stacks are required")
+		}
+		a, err := findStack(im, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		b, err := findStack(im, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+
+		var diffs []instance.ParamDiff
+		for _, d := range instance.DiffStacks(a, b) {
+			if !*changed || d.Kind != instance.ParamSame {
+				diffs = append(diffs, d)
+			}
+		}
+
+		// lines are colored after aligning them as escape sequences would throw
+		// off the alignment
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  PARAMETER\t%s\t%s\n", strings.ToUpper(a.Name), strings.ToUpper(b.Name))
+		for _, d := range diffs {
+			fmt.Fprintf(w, "%s %s\t%s\t%s\n", d.Kind.Mark(), d.Name, instance.FormatParamDef(d.A), instance.FormatParamDef(d.B))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		fmt.Fprintln(out, lines[0])
+		for i, line := range lines[1:] {
+			if diffs[i].Kind != instance.ParamSame {
+				line = diffs[i].Kind.Style().Render(line)
+			}
+			fmt.Fprintln(out, line)
+		}
+		return nil
+	}
+}
+
+// findStack returns the stack with given ID or name.
+func findStack(im *instance.Manager, nameOrID string) (*instance.Stack, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return im.Stack(id)
+	}
+	return im.StackByName(nameOrID)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func listUsers(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+	us, err := im.Users()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tGROUPS\tADMIN OF")
+	for _, u := range us {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", u.ID, u.Email, groupNames(u.Groups), groupNames(u.AdminGroups))
+	}
+	return w.Flush()
+}
+
+func groupNames(gs []instance.Group) string {
+	if len(gs) == 0 {
+		return "-"
+	}
+	var names []string
+	for _, g := range gs {
+		names = append(names, g.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func createUser(fs *flag.FlagSet) runFunc {
+	pwFile := fs.String("pw-file", "", "File containing the password of the new user, - reads it from stdin")
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if fs.NArg() != 1 {
+			return errors.New("email of the user is required")
+		}
+
+		pw, err := instance.PasswordSource{File: *pwFile, User: fs.Arg(0)}.Read()
+		if err != nil {
+			return err
+		}
+		u, err := im.CreateUser(fs.Arg(0), pw)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "created user %q with id %d\n", u.Email, u.ID)
+		return nil
+	}
+}
+
+// changeGroup returns a command changing the membership of a user in a group
+// using change. The format of the message printed afterwards is given the
+// user and the group.
+func changeGroup(change func(im *instance.Manager, group string, userID int) error, format string) runFunc {
+	return func(im *instance.Manager, cfg *instance.Config, args []string, out io.Writer) error {
+		if len(args) != 2 {
+			return errors.New("group name and user email or id are required")
+		}
+		id, err := im.GroupUserID(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if err := change(im, args[0], id); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, format+"\n", args[1], args[0])
+		return nil
+	}
+}
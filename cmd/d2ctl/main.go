@@ -5,11 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	instance "github.com/teleivo/dhis2-im-manager-cli"
+	"golang.org/x/term"
 )
 
 func main() {
@@ -19,32 +20,67 @@ func main() {
 	}
 }
 
-func run(args []string, out io.Writer) error {
+func run(args []string, out io.Writer) (err error) {
 	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
-	url := fs.String("url", "", "Instance manager URL")
-	user := fs.String("user", "", "User to login and perform actions on the instance manager")
-	pw := fs.String("pw", "", "Password of user")
-	err := fs.Parse(args[1:])
+	var flags instance.ManagerFlags
+	flags.Register(fs, "the log file")
+	logFile := fs.String("log-file", "", "File to log to as the terminal is used by the UI, enables -verbose")
+	ratio := fs.String("ratio", "1:2", "Ratio of the list to the detail pane width")
+	refresh := fs.Duration("refresh", 30*time.Second, "Interval in which instances are refreshed, 0 disables it")
+	noAltScreen := fs.Bool("no-alt-screen", false, "Render the UI inline instead of in the alternate screen of the terminal")
+	collapse := fs.Int("collapse-width", instance.DefaultLayout.CollapseWidth, "Terminal width below which only one pane is shown at a time")
+	err = fs.Parse(args[1:])
 	if err != nil {
 		return err
 	}
-	if *url == "" || *user == "" || *pw == "" {
-		return errors.New("url, user and pw are required")
+	if (flags.Verbose || flags.Trace) && *logFile == "" {
+		return errors.New("-verbose and -trace require a -log-file as the terminal is used by the UI")
 	}
+	layout := instance.DefaultLayout
+	layout.ListRatio, layout.DetailRatio, err = instance.ParseRatio(*ratio)
+	if err != nil {
+		return err
+	}
+	layout.CollapseWidth = *collapse
 
-	// TODO set some timeouts
-	client := &http.Client{}
-	im := instance.NewManager(*url, *user, *pw, client)
-	err = im.Login()
+	if *logFile != "" {
+		f, err := os.OpenFile(*logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		flags.Log = f
+		flags.Verbose = true
+	}
+	// the UI needs a terminal to read keys from and to render to. Without one
+	// the password cannot be prompted for either.
+	interactive := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+	flags.NoPrompt = !interactive
+	im, cfg, closeManager, err := instance.NewManagerFromFlags(flags)
+	defer func() {
+		if cerr := closeManager(); err == nil {
+			err = cerr
+		}
+	}()
 	if err != nil {
 		return err
 	}
 
-	m := instance.NewStacks(im)
-	ui := instance.NewUI(im, m)
+	instance.ConfigureColors()
+	if !interactive {
+		return printPlain(im, out)
+	}
+
+	ui := instance.NewUI(im,
+		instance.NewStacks(im, layout, cfg.Presets),
+		instance.NewInstances(im, layout, *refresh),
+	)
 
-	p := tea.NewProgram(ui, tea.WithAltScreen())
+	var opts []tea.ProgramOption
+	if !*noAltScreen {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(ui, opts...)
 
-	_ = out
 	return p.Start()
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+// printPlain prints the stacks and instances shown in the tabs of the UI as
+// plain text. It is used instead of the UI if not run in a terminal.
+func printPlain(im *instance.Manager, out io.Writer) error {
+	sts, err := im.Stacks()
+	if err != nil {
+		return err
+	}
+	ins, err := im.Instances()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STACKS")
+	fmt.Fprintln(w, "ID\tNAME")
+	for _, st := range sts {
+		fmt.Fprintf(w, "%d\t%s\n", st.ID, st.Name)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "INSTANCES")
+	fmt.Fprintln(w, "ID\tNAME\tGROUP\tSTATUS\tEXPIRES")
+	for _, in := range ins {
+		expires := "never"
+		if in.TTL() > 0 {
+			left := time.Until(in.Expiry())
+			expires = "expired"
+			if left > 0 {
+				expires = "in " + instance.FormatDuration(left)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", in.ID, in.Name, in.GroupName, in.Status, expires)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	instance "github.com/teleivo/dhis2-im-manager-cli"
+)
+
+func TestPrintPlain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stacks/":
+			fmt.Fprint(w, `[{"ID":1,"name":"dhis2"},{"ID":2,"name":"dhis2-core"}]`)
+		case "/instances":
+			fmt.Fprint(w, `[{"Name":"qa","Instances":[{"ID":7,"Name":"dev","StackID":1,"Status":"Running"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	im := instance.NewManager(srv.URL, "user", "pw", srv.Client())
+
+	var out bytes.Buffer
+	if err := printPlain(im, &out); err != nil {
+		t.Fatalf("printPlain failed: %s", err)
+	}
+
+	want := `STACKS
+ID  NAME
+1   dhis2
+2   dhis2-core
+
+INSTANCES
+ID  NAME  GROUP  STATUS   EXPIRES
+7   dev   qa     Running  never
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("printPlain mismatch (-want +got): %s\n", diff)
+	}
+}
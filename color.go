@@ -0,0 +1,17 @@
+package instance
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// ConfigureColors disables colors if the NO_COLOR environment variable is
+// set to a non-empty value as described on https://no-color.org. Other
+// styles like bold text are kept.
+func ConfigureColors() {
+	if os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
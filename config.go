@@ -0,0 +1,139 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Config holds defaults for the flags of the binaries. It is read from
+// config.json in the dhis2-im directory of the users config directory or the
+// file named by the DHIS2_IM_CONFIG environment variable.
+type Config struct {
+	URL   string      `json:"url"`
+	User  string      `json:"user"`
+	Auth  AuthConfig  `json:"auth"`
+	Cache CacheConfig `json:"cache"`
+	// Presets are parameters values per stack that can be used when creating
+	// instances.
+	Presets Presets `json:"presets"`
+}
+
+// CacheConfig configures the cache of responses.
+type CacheConfig struct {
+	// TTL overrides the DefaultCacheTTLs per path prefix like
+	// {"/stacks": "7d"}.
+	TTL map[string]string `json:"ttl"`
+}
+
+// Authentication methods that can be configured.
+const (
+	// AuthPassword logs in with the password of the user which is prompted
+	// for or looked up in a keyring.
+	AuthPassword = "password"
+	// AuthToken uses a pre-issued access token.
+	AuthToken = "token"
+	// AuthRefreshToken exchanges a refresh token for access tokens.
+	AuthRefreshToken = "refresh-token"
+	// AuthServiceAccount logs in with the password of a user dedicated to
+	// automation. The password is never prompted for.
+	AuthServiceAccount = "service-account"
+)
+
+// AuthConfig configures how to authenticate. The secret, a token or a
+// password depending on the method, is read from the environment variable Env
+// or from File so it does not need to be stored in the config.
+type AuthConfig struct {
+	Method string `json:"method"`
+	Env    string `json:"env"`
+	File   string `json:"file"`
+}
+
+// DefaultConfigPath returns the path the config is read from if none is
+// given.
+func DefaultConfigPath() (string, error) {
+	if p := os.Getenv("DHIS2_IM_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dhis2-im", "config.json"), nil
+}
+
+// LoadConfig reads the config from the file at path. An empty config is
+// returned if path is empty and there is no file at the default path.
+func LoadConfig(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		if path, err = DefaultConfigPath(); err != nil {
+			return &Config{}, nil
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Authenticator returns the authenticator of the configured method. A nil
+// Authenticator is returned for the password method as the password is
+// provided by the user.
+func (c AuthConfig) Authenticator(user string) (Authenticator, error) {
+	switch c.Method {
+	case AuthPassword, "":
+		return nil, nil
+	case AuthToken:
+		t, err := c.secret()
+		if err != nil {
+			return nil, err
+		}
+		return BearerToken{Token: t}, nil
+	case AuthRefreshToken:
+		t, err := c.secret()
+		if err != nil {
+			return nil, err
+		}
+		return NewRefreshToken(t), nil
+	case AuthServiceAccount:
+		if user == "" {
+			return nil, errors.New("user of the service account is required")
+		}
+		pw, err := c.secret()
+		if err != nil {
+			return nil, err
+		}
+		return BasicAuth{User: user, Password: pw}, nil
+	}
+	return nil, fmt.Errorf("unknown auth method %q, expected one of %s", c.Method,
+		strings.Join([]string{AuthPassword, AuthToken, AuthRefreshToken, AuthServiceAccount}, ", "))
+}
+
+func (c AuthConfig) secret() (string, error) {
+	if c.Env != "" {
+		if s := os.Getenv(c.Env); s != "" {
+			return s, nil
+		}
+		if c.File == "" {
+			return "", fmt.Errorf("environment variable %s of auth method %s is not set", c.Env, c.Method)
+		}
+	}
+	if c.File != "" {
+		return readPasswordFile(c.File)
+	}
+	return "", fmt.Errorf("auth method %s needs an env or file to read the secret from", c.Method)
+}
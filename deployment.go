@@ -0,0 +1,233 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CoreStack is the name of the stack deploying DHIS2 without a database.
+	CoreStack = "dhis2-core"
+	// DBStack is the name of the stack deploying a DHIS2 database.
+	DBStack = "dhis2-db"
+
+	// dbSuffix is appended to the name of a deployment to name its database
+	// instance.
+	dbSuffix = "-db"
+	// dbHostParam is the parameter of the core instance that links it to the
+	// database instance.
+	dbHostParam = "DATABASE_HOSTNAME"
+)
+
+// pollInterval is the interval in which the status of the instances of a
+// deployment is fetched while waiting for them.
+var pollInterval = 5 * time.Second
+
+// sharedDBParams are the parameters of the database instance that are passed
+// on to the core instance so it can connect to it.
+var sharedDBParams = []string{"DATABASE_NAME", "DATABASE_USERNAME", "DATABASE_PASSWORD"}
+
+// Deployment is a DHIS2 core instance linked to its own database instance.
+// Both are deployed to the same group and are treated as one unit.
+type Deployment struct {
+	Name string
+	Core Instance
+	DB   Instance
+}
+
+// Status returns the status of the deployment which is the status of the
+// core instance unless the database instance is not running.
+func (d Deployment) Status() string {
+	if !strings.EqualFold(d.DB.Status, StatusRunning) {
+		return "database " + d.DB.Status
+	}
+	return d.Core.Status
+}
+
+// dbHost returns the hostname of the database service of the database
+// instance.
+func dbHost(db Instance) string {
+	return db.Name + "-" + DBService + "-postgresql"
+}
+
+// CreateDeployment creates a database instance, waits for it to run and then
+// creates a core instance wired to it. The dbParams and coreParams are passed
+// on to the respective instance. The database instance is deleted if it does
+// not run or the core instance cannot be created.
+func (m *Manager) CreateDeployment(ctx context.Context, name string, group int, dbParams, coreParams map[string]string) (*Deployment, error) {
+	dbStack, err := m.StackByName(DBStack)
+	if err != nil {
+		return nil, err
+	}
+	coreStack, err := m.StackByName(CoreStack)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := m.Create(name+dbSuffix, group, dbStack.ID, dbParams)
+	if err != nil {
+		return nil, err
+	}
+	db, err := m.WaitUntilRunning(ctx, created.ID, pollInterval)
+	if err != nil {
+		return nil, m.deleteDB(*created, err)
+	}
+
+	params := linkParams(*db, coreStack, coreParams)
+	core, err := m.Create(name, group, coreStack.ID, params)
+	if err != nil {
+		return nil, m.deleteDB(*db, err)
+	}
+
+	return &Deployment{Name: name, Core: *core, DB: *db}, nil
+}
+
+// deleteDB deletes the database instance of a deployment that failed with
+// err. The returned error includes the failure to delete the instance.
+func (m *Manager) deleteDB(db Instance, err error) error {
+	if derr := m.Delete(db.ID); derr != nil {
+		return fmt.Errorf("%s (deleting database instance %q failed: %s)", err, db.Name, derr)
+	}
+	return err
+}
+
+// linkParams returns the params of the core instance wired to the database
+// instance. Params given by the user take precedence.
+func linkParams(db Instance, core *Stack, params map[string]string) map[string]string {
+	defined := make(map[string]bool)
+	for _, p := range core.RequiredParams {
+		defined[p.Name] = true
+	}
+	for _, p := range core.OptionalParams {
+		defined[p.Name] = true
+	}
+
+	linked := make(map[string]string)
+	if defined[dbHostParam] {
+		linked[dbHostParam] = dbHost(db)
+	}
+	for _, name := range sharedDBParams {
+		if v, ok := db.Param(name); ok && defined[name] {
+			linked[name] = v
+		}
+	}
+	for k, v := range params {
+		linked[k] = v
+	}
+	return linked
+}
+
+// Deployments returns all deployments in the groups of the user. A core
+// instance and the instance of the same group named like it with suffix
+// "-db" form a deployment.
+func (m *Manager) Deployments() ([]Deployment, error) {
+	ins, err := m.Instances()
+	if err != nil {
+		return nil, err
+	}
+	sts, err := m.Stacks()
+	if err != nil {
+		return nil, err
+	}
+	stacks := make(map[int]string)
+	for _, st := range sts {
+		stacks[st.ID] = st.Name
+	}
+
+	type key struct {
+		group, name string
+	}
+	dbs := make(map[key]Instance)
+	for _, in := range ins {
+		if stacks[in.StackID] == DBStack {
+			dbs[key{in.GroupName, in.Name}] = in
+		}
+	}
+	var ds []Deployment
+	for _, in := range ins {
+		if stacks[in.StackID] != CoreStack {
+			continue
+		}
+		db, ok := dbs[key{in.GroupName, in.Name + dbSuffix}]
+		if !ok {
+			continue
+		}
+		ds = append(ds, Deployment{Name: in.Name, Core: in, DB: db})
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].Name < ds[j].Name
+	})
+
+	return ds, nil
+}
+
+// DeploymentByName returns the deployment with given name.
+func (m *Manager) DeploymentByName(name string) (*Deployment, error) {
+	ds, err := m.Deployments()
+	if err != nil {
+		return nil, err
+	}
+	var found []Deployment
+	for _, d := range ds {
+		if d.Name == name {
+			found = append(found, d)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("deployment %q not found", name)
+	case 1:
+		return &found[0], nil
+	}
+	return nil, fmt.Errorf("deployment name %q is ambiguous: found it in %d groups", name, len(found))
+}
+
+// DeleteDeployment deletes the core and then the database instance of the
+// deployment.
+func (m *Manager) DeleteDeployment(d Deployment) error {
+	if err := m.Delete(d.Core.ID); err != nil {
+		return err
+	}
+	return m.Delete(d.DB.ID)
+}
+
+// RestartDeployment restarts the database instance, waits for it to run again
+// and then restarts the core instance.
+func (m *Manager) RestartDeployment(ctx context.Context, d Deployment) error {
+	if err := m.Restart(d.DB.ID); err != nil {
+		return err
+	}
+	if err := m.waitUntilRestarted(ctx, d.DB); err != nil {
+		return err
+	}
+	if _, err := m.WaitUntilRunning(ctx, d.DB.ID, pollInterval); err != nil {
+		return err
+	}
+	return m.Restart(d.Core.ID)
+}
+
+// waitUntilRestarted waits until the restarted instance is no longer running
+// as it was before the restart. An instance that is updated after it was
+// fetched restarted in between polls.
+func (m *Manager) waitUntilRestarted(ctx context.Context, before Instance) error {
+	t := time.NewTicker(pollInterval)
+	defer t.Stop()
+	for {
+		in, err := m.Instance(before.ID)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(in.Status, StatusRunning) || in.UpdatedAt.After(before.UpdatedAt) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for instance %q to restart: %w", in.Name, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package instance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLinkParams(t *testing.T) {
+	db := Instance{
+		Name: "release-db",
+		OptionalParams: []InstanceParam{
+			{Name: "DATABASE_NAME", Value: "dhis2"},
+			{Name: "DATABASE_PASSWORD", Value: "secret"},
+		},
+	}
+	core := &Stack{
+		Name: CoreStack,
+		RequiredParams: []RequiredParam{
+			{Name: "DATABASE_HOSTNAME"},
+		},
+		OptionalParams: []OptionalParam{
+			{Name: "DATABASE_NAME"},
+			{Name: "IMAGE_TAG"},
+		},
+	}
+
+	got := linkParams(db, core, map[string]string{"IMAGE_TAG": "2.38.0", "DATABASE_NAME": "other"})
+
+	want := map[string]string{
+		"DATABASE_HOSTNAME": "release-db-database-postgresql",
+		"DATABASE_NAME":     "other",
+		"IMAGE_TAG":         "2.38.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("linkParams mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestCreateDeploymentDeletesDatabaseIfItFailsToRun(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /stacks/":
+			_, _ = w.Write([]byte(`[{"ID":1,"Name":"dhis2-db"},{"ID":2,"Name":"dhis2-core"}]`))
+		case "GET /stacks/1":
+			_, _ = w.Write([]byte(`{"ID":1,"Name":"dhis2-db"}`))
+		case "GET /stacks/2":
+			_, _ = w.Write([]byte(`{"ID":2,"Name":"dhis2-core"}`))
+		case "POST /instances":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"ID":7,"Name":"release-db","StackID":1}`))
+		case "GET /instances/7":
+			_, _ = w.Write([]byte(`{"ID":7,"Name":"release-db","StackID":1,"Status":"Error"}`))
+		case "DELETE /instances/7":
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+	if _, err := m.CreateDeployment(context.Background(), "release", 2, nil, nil); err == nil {
+		t.Fatal("CreateDeployment succeeded, want error")
+	}
+
+	if diff := cmp.Diff([]string{"/instances/7"}, deleted); diff != "" {
+		t.Errorf("deleted instances mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestRestartDeploymentWaitsForTheDatabaseToRestart(t *testing.T) {
+	interval := pollInterval
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = interval }()
+	// the database is still running until the restart is picked up
+	statuses := []string{"Running", "Running", "Pending", "Booting", "Running"}
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "PUT /instances/7/restart", "PUT /instances/8/restart":
+			events = append(events, "restart "+r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		case "GET /instances/7":
+			status := statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			events = append(events, status)
+			fmt.Fprintf(w, `{"ID":7,"Name":"release-db","UpdatedAt":"2024-05-01T10:00:00Z","Status":%q}`, status)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+	d := Deployment{
+		Name: "release",
+		Core: Instance{ID: 8, Name: "release"},
+		DB:   Instance{ID: 7, Name: "release-db", UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Status: "Running"},
+	}
+
+	if err := m.RestartDeployment(context.Background(), d); err != nil {
+		t.Fatalf("RestartDeployment failed: %s", err)
+	}
+
+	want := []string{"restart /instances/7/restart", "Running", "Running", "Pending", "Booting", "Running", "restart /instances/8/restart"}
+	if diff := cmp.Diff(want, events); diff != "" {
+		t.Errorf("events mismatch (-want +got): %s\n", diff)
+	}
+}
@@ -0,0 +1,99 @@
+package instance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+// ParseDuration parses a duration like time.ParseDuration. It additionally
+// accepts the units "d" for days and "w" for weeks as in "7d" or "1w2d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	in := strings.TrimSpace(s)
+	if in == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var d time.Duration
+	var rest strings.Builder
+	for in != "" {
+		i := 0
+		for i < len(in) && (in[i] >= '0' && in[i] <= '9' || in[i] == '.') {
+			i++
+		}
+		j := i
+		for j < len(in) && (in[j] < '0' || in[j] > '9') && in[j] != '.' {
+			j++
+		}
+		num, unit := in[:i], strings.TrimSpace(in[i:j])
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			if unit == "d" {
+				d += time.Duration(n * float64(day))
+			} else {
+				d += time.Duration(n * float64(week))
+			}
+		default:
+			rest.WriteString(num + unit)
+		}
+		in = in[j:]
+	}
+
+	if rest.Len() > 0 {
+		r, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += r
+	}
+	return d, nil
+}
+
+// FormatDuration formats the duration in days, hours and minutes like
+// "2d 4h 5m". Durations below a minute are formatted in seconds.
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+
+	d = d.Round(time.Minute)
+	days := d / day
+	d -= days * day
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatTime formats the time in the local time zone like
+// "2024-05-01 10:00:05". A zero time is formatted as "-".
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
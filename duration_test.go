@@ -0,0 +1,70 @@
+package instance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{in: "24h", want: 24 * time.Hour},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: "1w2d12h", want: 9*24*time.Hour + 12*time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseDuration(tc.in)
+		if err != nil {
+			t.Fatalf("ParseDuration(%s) failed: %s", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("ParseDuration(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "d", "7x", "abc"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) expected an error", in)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 30 * time.Second, want: "30s"},
+		{in: 90 * time.Minute, want: "1h 30m"},
+		{in: 50*time.Hour + 5*time.Minute, want: "2d 2h 5m"},
+		{in: 48 * time.Hour, want: "2d"},
+		{in: -2 * time.Hour, want: "-2h"},
+	}
+
+	for _, tc := range tests {
+		if got := FormatDuration(tc.in); got != tc.want {
+			t.Errorf("FormatDuration(%s) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{in: time.Date(2024, 5, 1, 10, 0, 5, 0, time.Local), want: "2024-05-01 10:00:05"},
+		{in: time.Time{}, want: "-"},
+	}
+
+	for _, tc := range tests {
+		if got := FormatTime(tc.in); got != tc.want {
+			t.Errorf("FormatTime(%s) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package instance
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ManagerFlags are the flags shared by the binaries to connect to and
+// authenticate with the instance manager. Flags that are not set default to
+// the values of the config.
+type ManagerFlags struct {
+	Config       string
+	URL          string
+	User         string
+	Password     string
+	PasswordFile string
+	Keyring      string
+	SavePassword bool
+	NoCache      bool
+	Record       string
+	Replay       string
+	Verbose      bool
+	Trace        bool
+	// Log is written to if Verbose or Trace is set.
+	Log io.Writer
+	// NoPrompt fails instead of prompting for the password if no other
+	// source provides it.
+	NoPrompt bool
+	// Timeout limits the time of requests to the instance manager. There is
+	// no limit if it is zero.
+	Timeout time.Duration
+}
+
+// Register defines the flags in fs. Requests are logged to logDest like
+// "stderr" if Verbose or Trace is set.
+func (f *ManagerFlags) Register(fs *flag.FlagSet, logDest string) {
+	fs.StringVar(&f.Config, "config", "", "Config file providing defaults and the auth method (default is dhis2-im/config.json in the user config directory)")
+	fs.StringVar(&f.URL, "url", "", "Instance manager URL")
+	fs.StringVar(&f.User, "user", "", "User to login and perform actions on the instance manager")
+	fs.StringVar(&f.Password, "pw", "", "Password of user (insecure as it shows up in the process list and shell history, prefer -pw-file or the prompt)")
+	fs.StringVar(&f.PasswordFile, "pw-file", "", "File containing the password of user, - reads it from stdin")
+	fs.StringVar(&f.Keyring, "keyring", KeyringAuto, "Keyring to look up and save passwords in: auto, secret-service, keychain, file or none")
+	fs.BoolVar(&f.SavePassword, "save-pw", false, "Save the password in the keyring after logging in successfully")
+	fs.BoolVar(&f.Verbose, "verbose", false, "Log requests sent to the instance manager to "+logDest)
+	fs.BoolVar(&f.NoCache, "no-cache", false, "Do not cache responses of the instance manager")
+	fs.StringVar(&f.Record, "record", "", "Record requests and responses with credentials redacted into given cassette file")
+	fs.StringVar(&f.Replay, "replay", "", "Replay responses from given cassette file instead of sending requests")
+	fs.BoolVar(&f.Trace, "trace", false, "Log requests including headers and bodies with credentials redacted to "+logDest)
+}
+
+// NewManagerFromFlags loads the config, reads the credentials of the
+// configured auth method and returns a manager that is logged in. The
+// returned close func writes the cassette if requests are recorded and must
+// be called once the manager is no longer used.
+func NewManagerFromFlags(f ManagerFlags) (*Manager, *Config, func() error, error) {
+	noop := func() error { return nil }
+	cfg, err := LoadConfig(f.Config)
+	if err != nil {
+		return nil, nil, noop, err
+	}
+	if f.URL == "" {
+		f.URL = cfg.URL
+	}
+	if f.User == "" {
+		f.User = cfg.User
+	}
+	var transport http.RoundTripper
+	if f.Replay != "" {
+		replayer, err := NewReplayer(f.Replay)
+		if err != nil {
+			return nil, nil, noop, err
+		}
+		if f.URL == "" {
+			f.URL = replayer.URL()
+		}
+		transport = replayer
+	}
+	if f.URL == "" {
+		return nil, nil, noop, errors.New("url is required")
+	}
+	var auth Authenticator
+	if f.Replay != "" {
+		// the recorded login response is replayed regardless of credentials
+		auth = BasicAuth{User: f.User}
+	} else if auth, err = cfg.Auth.Authenticator(f.User); err != nil {
+		return nil, nil, noop, err
+	}
+	var kr Keyring
+	var password string
+	if auth == nil {
+		if f.User == "" {
+			return nil, nil, noop, errors.New("user is required")
+		}
+		kr, err = NewKeyring(f.Keyring)
+		if err != nil {
+			return nil, nil, noop, err
+		}
+		password, err = PasswordSource{
+			Password: f.Password,
+			File:     f.PasswordFile,
+			Keyring:  kr,
+			Service:  KeyringService(f.URL),
+			User:     f.User,
+			NoPrompt: f.NoPrompt,
+		}.Read()
+		if err != nil {
+			return nil, nil, noop, err
+		}
+		auth = BasicAuth{User: f.User, Password: password}
+	}
+
+	// TODO set some timeouts
+	// the tracer is wrapped by the cache so that only requests actually sent
+	// to the instance manager are logged
+	if (f.Verbose || f.Trace) && f.Log != nil {
+		transport = NewTracer(transport, f.Log, f.Trace)
+	}
+	if !f.NoCache && f.Replay == "" {
+		ttls, err := ParseCacheTTLs(cfg.Cache.TTL)
+		if err != nil {
+			return nil, nil, noop, err
+		}
+		dir, err := CacheDir(f.URL, auth)
+		if err != nil {
+			return nil, nil, noop, err
+		}
+		transport = NewCache(transport, dir, ttls)
+	}
+	// the recorder wraps the cache so that cassettes hold the responses the
+	// manager got including cache hits instead of bodiless revalidations
+	closer := noop
+	if f.Record != "" {
+		rec := NewRecorder(transport, f.Record)
+		closer = rec.Close
+		transport = rec
+	}
+	client := &http.Client{Transport: transport, Timeout: f.Timeout}
+	im := NewManagerWithAuth(f.URL, f.User, auth, client)
+	if err := im.Login(); err != nil {
+		return nil, nil, closer, err
+	}
+	if f.SavePassword && kr != nil {
+		if err := kr.Set(KeyringService(f.URL), f.User, password); err != nil {
+			return nil, nil, closer, err
+		}
+	}
+	return im, cfg, closer, nil
+}
@@ -0,0 +1,103 @@
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecordWithCacheAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("DHIS2_IM_CONFIG", filepath.Join(dir, "config.json"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+		case "/instances":
+			// instances are not cached for a TTL but revalidated
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			fmt.Fprint(w, `[{"Name":"qa","Instances":[{"ID":7,"Name":"dev"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cassette := filepath.Join(dir, "cassette.json")
+
+	fetch := func(flags ManagerFlags) []string {
+		t.Helper()
+		m, _, closeManager, err := NewManagerFromFlags(flags)
+		if err != nil {
+			t.Fatalf("NewManagerFromFlags failed: %s", err)
+		}
+		var names []string
+		for i := 0; i < 2; i++ {
+			ins, err := m.Instances()
+			if err != nil {
+				t.Fatalf("Instances failed: %s", err)
+			}
+			for _, in := range ins {
+				names = append(names, in.Name)
+			}
+		}
+		if err := closeManager(); err != nil {
+			t.Fatalf("closing the manager failed: %s", err)
+		}
+		return names
+	}
+
+	want := fetch(ManagerFlags{URL: srv.URL, User: "user", Password: "pw", Keyring: KeyringNone, Record: cassette})
+	got := fetch(ManagerFlags{User: "user", Replay: cassette})
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Replayed instances mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestTraceOmitsCacheHits(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("DHIS2_IM_CONFIG", filepath.Join(dir, "config.json"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+		case "/stacks/":
+			fmt.Fprint(w, `[{"ID":1,"name":"dhis2"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var log bytes.Buffer
+	m, _, _, err := NewManagerFromFlags(ManagerFlags{URL: srv.URL, User: "user", Password: "pw", Keyring: KeyringNone, Verbose: true, Log: &log})
+	if err != nil {
+		t.Fatalf("NewManagerFromFlags failed: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := m.Stacks(); err != nil {
+			t.Fatalf("Stacks failed: %s", err)
+		}
+	}
+
+	// stacks are cached for a day so only the first request is sent
+	if got := strings.Count(log.String(), "--> GET "+srv.URL+"/stacks/"); got != 1 {
+		t.Errorf("logged %d requests of stacks, want 1:\n%s", got, log.String())
+	}
+}
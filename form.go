@@ -0,0 +1,197 @@
+package instance
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var formLabel = lipgloss.NewStyle().PaddingRight(2)
+
+var formKeys = struct {
+	Next   key.Binding
+	Prev   key.Binding
+	Submit key.Binding
+	Cancel key.Binding
+}{
+	Next: key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous"),
+	),
+	Submit: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "review"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+// paramForm edits the parameters of an instance. Submitting the form shows
+// the changes to review them before the command returned by onSubmit is run
+// with the changed parameters.
+type paramForm struct {
+	instance Instance
+	names    []string
+	inputs   []textinput.Model
+	focus    int
+	// review is true while the changes are shown for confirmation.
+	review   bool
+	changes  []ParamChange
+	active   bool
+	onSubmit func(params map[string]string) tea.Cmd
+	width    int
+	height   int
+}
+
+// edit activates the form with the parameters of the instance.
+func (f *paramForm) edit(in Instance, onSubmit func(params map[string]string) tea.Cmd) tea.Cmd {
+	params := in.Params()
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	*f = paramForm{
+		instance: in,
+		names:    names,
+		active:   true,
+		onSubmit: onSubmit,
+		width:    f.width,
+		height:   f.height,
+	}
+	for _, name := range names {
+		in := textinput.New()
+		in.Prompt = ""
+		in.SetValue(params[name])
+		f.inputs = append(f.inputs, in)
+	}
+	return f.focusInput(0)
+}
+
+func (f *paramForm) close() {
+	f.active = false
+	f.onSubmit = nil
+	f.inputs = nil
+}
+
+func (f *paramForm) focusInput(i int) tea.Cmd {
+	if len(f.inputs) == 0 {
+		return nil
+	}
+	f.inputs[f.focus].Blur()
+	f.focus = (i + len(f.inputs)) % len(f.inputs)
+	f.inputs[f.focus].CursorEnd()
+	return f.inputs[f.focus].Focus()
+}
+
+// params returns the parameters that differ from the ones of the instance.
+func (f paramForm) params() map[string]string {
+	params := make(map[string]string)
+	for _, c := range f.changes {
+		params[c.Name] = c.New
+	}
+	return params
+}
+
+func (f paramForm) update(msg tea.Msg) (paramForm, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if f.review {
+			switch {
+			case key.Matches(msg, formKeys.Submit):
+				submit, params := f.onSubmit, f.params()
+				f.close()
+				if submit == nil {
+					return f, nil
+				}
+				return f, submit(params)
+			case key.Matches(msg, formKeys.Cancel):
+				f.review = false
+				return f, f.focusInput(f.focus)
+			}
+			return f, nil
+		}
+
+		switch {
+		case key.Matches(msg, formKeys.Next):
+			return f, f.focusInput(f.focus + 1)
+		case key.Matches(msg, formKeys.Prev):
+			return f, f.focusInput(f.focus - 1)
+		case key.Matches(msg, formKeys.Submit):
+			values := make(map[string]string)
+			for i, name := range f.names {
+				values[name] = f.inputs[i].Value()
+			}
+			f.changes = DiffParams(f.instance, values)
+			if len(f.changes) == 0 {
+				f.close()
+				return f, setStatus("parameters of %s are unchanged", f.instance.Name)
+			}
+			f.review = true
+			f.inputs[f.focus].Blur()
+			return f, nil
+		case key.Matches(msg, formKeys.Cancel):
+			f.close()
+			return f, nil
+		}
+	}
+
+	if len(f.inputs) == 0 {
+		return f, nil
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return f, cmd
+}
+
+func (f paramForm) view() string {
+	var b strings.Builder
+	b.WriteString(detailTitle.Render("Parameters of "+f.instance.Name) + "\n")
+
+	if f.review {
+		var rows [][]string
+		for _, c := range f.changes {
+			old := RemovedText.Render(c.Old)
+			if c.Added {
+				old = "-"
+			}
+			rows = append(rows, []string{ChangedText.Render(c.Name), old, AddedText.Render(c.New)})
+		}
+		b.WriteString(renderTable([]string{"PARAMETER", "OLD", "NEW"}, rows) + "\n\n")
+		b.WriteString(errorHint.Render("enter redeploy • esc edit"))
+		return b.String()
+	}
+
+	labelWidth := 0
+	for _, name := range f.names {
+		labelWidth = max(labelWidth, lipgloss.Width(name))
+	}
+	// show the fields around the focused one if they do not fit
+	visible := len(f.inputs)
+	if f.height > 0 {
+		visible = max(1, f.height-4)
+	}
+	start := 0
+	if f.focus >= visible {
+		start = f.focus - visible + 1
+	}
+	for i := start; i < len(f.inputs) && i < start+visible; i++ {
+		label := f.names[i]
+		if i == f.focus {
+			label = tableHeader.Render(label)
+		}
+		b.WriteString(formLabel.Copy().Width(labelWidth+2).Render(label) + f.inputs[i].View() + "\n")
+	}
+	b.WriteString("\n" + errorHint.Render("↑/↓ move • enter review changes • esc cancel"))
+	return b.String()
+}
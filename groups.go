@@ -0,0 +1,100 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Group of users sharing instances. Instances of a group are reachable under
+// the hostname of the group.
+type Group struct {
+	ID       int    `json:"ID"`
+	Name     string `json:"Name"`
+	Hostname string `json:"Hostname"`
+	Users    []User `json:"Users,omitempty"`
+}
+
+// IsMember returns true if the user is a member of the group.
+func (u *User) IsMember(group string) bool {
+	return hasGroup(u.Groups, group)
+}
+
+// IsAdmin returns true if the user administers the group.
+func (u *User) IsAdmin(group string) bool {
+	return hasGroup(u.AdminGroups, group)
+}
+
+func hasGroup(groups []Group, name string) bool {
+	for _, g := range groups {
+		if g.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups returns all groups sorted by name.
+func (m *Manager) Groups() ([]Group, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url+"/groups", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching groups", http.StatusOK, resp)
+	}
+
+	var gs []Group
+	if err := json.NewDecoder(resp.Body).Decode(&gs); err != nil {
+		return nil, err
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		return gs[i].Name < gs[j].Name
+	})
+	return gs, nil
+}
+
+// Group returns the group with given name including its members.
+func (m *Manager) Group(name string) (*Group, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url+"/groups/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching group", http.StatusOK, resp)
+	}
+
+	g := &Group{}
+	if err := json.NewDecoder(resp.Body).Decode(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GroupID returns the ID of the group with given name or ID.
+func (m *Manager) GroupID(nameOrID string) (int, error) {
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+	g, err := m.Group(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+	if g.ID == 0 {
+		return 0, fmt.Errorf("group %q has no ID", nameOrID)
+	}
+	return g.ID, nil
+}
@@ -0,0 +1,40 @@
+package instance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroupID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/groups/play ground" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ID":3,"Name":"play ground","Hostname":"play.dhis2.org"}`))
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "2", want: 2},
+		{in: "play ground", want: 3},
+	}
+	for _, tc := range tests {
+		got, err := m.GroupID(tc.in)
+		if err != nil {
+			t.Fatalf("GroupID(%q) failed: %s", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("GroupID(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+
+	if _, err := m.GroupID("unknown"); err == nil {
+		t.Error("GroupID of unknown group succeeded, want error")
+	}
+}
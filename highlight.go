@@ -0,0 +1,81 @@
+package instance
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	jsonKey     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#6124DF", Dark: "#7D56F4"})
+	jsonString  = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1F8A3B", Dark: "#A8CC8C"})
+	jsonNumber  = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#B35900", Dark: "#DBAB79"})
+	jsonLiteral = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#D1006A", Dark: "#FF5F87"})
+)
+
+// colorizeJSON highlights keys, strings, numbers and literals of indented
+// JSON as produced by json.MarshalIndent.
+func colorizeJSON(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case r == '"':
+			j := i + 1
+			for j < len(rs) && rs[j] != '"' {
+				if rs[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(rs))
+			tok := string(rs[i:j])
+			if isKey(rs[j:]) {
+				b.WriteString(jsonKey.Render(tok))
+			} else {
+				b.WriteString(jsonString.Render(tok))
+			}
+			i = j
+		case r == '-' || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(rs) && strings.ContainsRune("0123456789.eE+-", rs[j]) {
+				j++
+			}
+			b.WriteString(jsonNumber.Render(string(rs[i:j])))
+			i = j
+		case unicode.IsLetter(r):
+			j := i + 1
+			for j < len(rs) && unicode.IsLetter(rs[j]) {
+				j++
+			}
+			b.WriteString(jsonLiteral.Render(string(rs[i:j])))
+			i = j
+		default:
+			b.WriteRune(r)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isKey reports whether the string that was followed by rs is an object key.
+func isKey(rs []rune) bool {
+	for _, r := range rs {
+		if r == ':' {
+			return true
+		}
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,39 @@
+package instance
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renderInstance renders the attributes of the instance.
+func renderInstance(in Instance) string {
+	var b strings.Builder
+	b.WriteString(detailTitle.Render(in.Name+" ("+strconv.Itoa(in.ID)+")") + "\n")
+
+	rows := [][]string{
+		{"Group", in.GroupName},
+		{"Host", in.Hostname},
+		{"Stack", strconv.Itoa(in.StackID)},
+		{"Status", in.Status},
+		{"Created", FormatTime(in.CreatedAt)},
+		{"Updated", FormatTime(in.UpdatedAt)},
+	}
+	if ttl := in.TTL(); ttl > 0 {
+		left := time.Until(in.Expiry())
+		expires := "expired"
+		if left > 0 {
+			expires = "in " + FormatDuration(left)
+		}
+		if left < expiringSoon {
+			expires = expiringText.Render(expires)
+		}
+		rows = append(rows,
+			[]string{"TTL", FormatDuration(ttl)},
+			[]string{"Expires", expires + " at " + FormatTime(in.Expiry())},
+		)
+	}
+	b.WriteString(renderTable([]string{"ATTRIBUTE", "VALUE"}, rows))
+
+	return b.String()
+}
@@ -0,0 +1,430 @@
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type instances struct {
+	panes
+	prompt    prompt
+	form      paramForm
+	manager   *Manager
+	sort      sortMode
+	instances map[int]Instance
+	selected  map[int]bool
+	// group the shown instances belong to. All instances are shown if it is
+	// empty.
+	group string
+	// parallel is the number of instances operated on concurrently in bulk
+	// operations.
+	parallel int
+	// refresh is the interval in which instances are fetched in the
+	// background. A refresh of 0 disables it.
+	refresh time.Duration
+	// refreshID identifies the latest scheduled refresh so that refreshes
+	// scheduled before instances were fetched for other reasons are dropped.
+	refreshID int
+}
+
+var instancesKeys = struct {
+	Open           key.Binding
+	Clone          key.Binding
+	Edit           key.Binding
+	Select         key.Binding
+	ClearSelection key.Binding
+	Delete         key.Binding
+}{
+	Select: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "select"),
+	),
+	ClearSelection: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "clear selection"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "delete selected"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open in browser"),
+	),
+	Clone: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "clone"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit parameters"),
+	),
+}
+
+// NewInstances creates the instances component fetching the instances in the
+// background every refresh interval. A refresh of 0 disables it.
+func NewInstances(im *Manager, layout Layout, refresh time.Duration) instances {
+	return instances{
+		panes:    newPanes(layout),
+		prompt:   newPrompt(),
+		manager:  im,
+		selected: make(map[int]bool),
+		parallel: 4,
+		refresh:  refresh,
+	}
+}
+
+func (m instances) Title() string {
+	if m.group != "" {
+		return "Instances (" + m.group + ")"
+	}
+	return "Instances"
+}
+
+func (m instances) CapturingInput() bool {
+	return m.form.active || m.prompt.active || m.filtering()
+}
+
+func (m instances) Init() tea.Cmd {
+	return m.fetchInstances()
+}
+
+type refreshInstancesMsg struct {
+	id int
+}
+
+// scheduleRefresh schedules fetching the instances after the refresh
+// interval.
+func (m *instances) scheduleRefresh() tea.Cmd {
+	if m.refresh <= 0 {
+		return nil
+	}
+	m.refreshID++
+	id := m.refreshID
+	return tea.Tick(m.refresh, func(time.Time) tea.Msg {
+		return refreshInstancesMsg{id: id}
+	})
+}
+
+type instancesMsg struct {
+	instances []Instance
+}
+
+// fetchInstancesFailedMsg is sent if fetching the instances failed. The next
+// refresh is scheduled before the error is shown so that a transient error
+// does not stop refreshing in the background.
+type fetchInstancesFailedMsg struct {
+	err errMsg
+}
+
+func (m instances) fetchInstances() tea.Cmd {
+	return func() tea.Msg {
+		ins, err := m.manager.Instances()
+		if err != nil {
+			return fetchInstancesFailedMsg{err: errMsg{err: err, retry: m.fetchInstances()}}
+		}
+		return instancesMsg{instances: ins}
+	}
+}
+
+// items returns the items of the instances in the group.
+func (m instances) items() []list.Item {
+	var items []list.Item
+	for _, in := range m.instances {
+		if m.group != "" && in.GroupName != m.group {
+			continue
+		}
+		items = append(items, item{
+			id:        in.ID,
+			title:     fmt.Sprintf("%s (%s)", in.Name, in.GroupName),
+			filter:    filterValue(in.Name, strconv.Itoa(in.ID), in.GroupName, in.Status),
+			createdAt: in.CreatedAt,
+			updatedAt: in.UpdatedAt,
+			expiry:    in.Expiry(),
+			selected:  m.selected[in.ID],
+		})
+	}
+	return items
+}
+
+// unselectHidden unselects instances that no longer exist or are not in the
+// group so they are not deleted without being visible.
+func (m *instances) unselectHidden() {
+	for id := range m.selected {
+		in, ok := m.instances[id]
+		if !ok || m.group != "" && in.GroupName != m.group {
+			delete(m.selected, id)
+		}
+	}
+}
+
+// instancesChangedMsg is sent after instances were changed. The instances are
+// fetched again to show the change.
+type instancesChangedMsg struct {
+	status string
+	// failed is shown instead of the status if the change failed for some
+	// of the instances.
+	failed *errMsg
+}
+
+func (m instances) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tea.KeyMsg); ok && m.form.active {
+		var cmd tea.Cmd
+		m.form, cmd = m.form.update(msg)
+		return m, cmd
+	}
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		m.form.width, m.form.height = msg.Width, msg.Height
+	}
+	if _, ok := msg.(tea.KeyMsg); ok && m.prompt.active {
+		var cmd tea.Cmd
+		m.prompt, cmd = m.prompt.update(msg)
+		if !m.prompt.active {
+			m.reserve(0)
+		}
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case instancesMsg:
+		m.instances = make(map[int]Instance)
+		for _, in := range msg.instances {
+			m.instances[in.ID] = in
+		}
+		m.unselectHidden()
+		cmd, _ := m.setItems(m.items(), m.sort)
+		m.showInstance()
+		return m, tea.Batch(cmd, m.scheduleRefresh(), setStatus("fetched %d instances", len(msg.instances)))
+	case fetchInstancesFailedMsg:
+		return m, tea.Batch(m.scheduleRefresh(), func() tea.Msg { return msg.err })
+	case groupMsg:
+		m.group = msg.group
+		m.unselectHidden()
+		cmd, _ := m.setItems(m.items(), m.sort)
+		m.showInstance()
+		return m, cmd
+	case refreshInstancesMsg:
+		if msg.id != m.refreshID {
+			return m, nil
+		}
+		return m, m.fetchInstances()
+	case instancesChangedMsg:
+		if msg.failed != nil {
+			return m, tea.Batch(m.fetchInstances(), func() tea.Msg { return *msg.failed })
+		}
+		return m, tea.Batch(setStatus("%s", msg.status), m.fetchInstances())
+	case tickMsg:
+		// count down the time left until the instance expires
+		m.showInstance()
+		return m, nil
+	case tea.KeyMsg:
+		if m.filtering() {
+			break
+		}
+		switch {
+		case key.Matches(msg, listKeys.Sort):
+			m.sort = m.sort.next()
+			cmd, _ := m.setItems(m.list.Items(), m.sort)
+			m.showInstance()
+			return m, tea.Batch(cmd, setStatus("sorted instances by %s", m.sort))
+		case key.Matches(msg, instancesKeys.Open):
+			in, ok := m.instances[m.curID]
+			if !ok {
+				return m, nil
+			}
+			return m, m.openInstance(in)
+		case key.Matches(msg, instancesKeys.Select):
+			if _, ok := m.instances[m.curID]; !ok {
+				return m, nil
+			}
+			m.selected[m.curID] = !m.selected[m.curID]
+			if !m.selected[m.curID] {
+				delete(m.selected, m.curID)
+			}
+			cmd, _ := m.setItems(m.markSelected(m.list.Items()), m.sort)
+			return m, cmd
+		case key.Matches(msg, instancesKeys.ClearSelection):
+			m.selected = make(map[int]bool)
+			cmd, _ := m.setItems(m.markSelected(m.list.Items()), m.sort)
+			return m, cmd
+		case key.Matches(msg, instancesKeys.Delete):
+			ins := m.selectedInstances()
+			if len(ins) == 0 {
+				return m, nil
+			}
+			var names []string
+			for _, in := range ins {
+				names = append(names, in.Name)
+			}
+			m.reserve(promptHeight)
+			question := fmt.Sprintf("Delete %d instances (%s)? [y/N]", len(ins), strings.Join(names, ", "))
+			cmd := m.prompt.ask(question, "", func(answer string) tea.Cmd {
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					return setStatus("deletion aborted")
+				}
+				return m.deleteInstances(ins)
+			})
+			return m, cmd
+		case key.Matches(msg, instancesKeys.Edit):
+			in, ok := m.instances[m.curID]
+			if !ok {
+				return m, nil
+			}
+			cmd := m.form.edit(in, func(params map[string]string) tea.Cmd {
+				return m.updateParams(in, params)
+			})
+			return m, cmd
+		case key.Matches(msg, instancesKeys.Clone):
+			in, ok := m.instances[m.curID]
+			if !ok {
+				return m, nil
+			}
+			m.reserve(promptHeight)
+			cmd := m.prompt.ask("Name of the clone of "+in.Name+":", in.Name+"-clone", func(name string) tea.Cmd {
+				return m.cloneInstance(in, name)
+			})
+			return m, cmd
+		}
+	}
+
+	var cmds []tea.Cmd
+	if m.prompt.active {
+		// let the prompt blink its cursor
+		var cmd tea.Cmd
+		m.prompt, cmd = m.prompt.update(msg)
+		cmds = append(cmds, cmd)
+	}
+	if m.form.active {
+		var cmd tea.Cmd
+		m.form, cmd = m.form.update(msg)
+		cmds = append(cmds, cmd)
+	}
+	cmd, changed := m.update(msg)
+	cmds = append(cmds, cmd)
+	if changed {
+		m.showInstance()
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m instances) openInstance(in Instance) tea.Cmd {
+	return func() tea.Msg {
+		u, err := m.manager.InstanceURL(&in)
+		if err != nil {
+			return errMsg{err: err, retry: m.openInstance(in)}
+		}
+		if err := OpenBrowser(u); err != nil {
+			return errMsg{err: err}
+		}
+		return statusMsg{text: "opened " + u}
+	}
+}
+
+func (m instances) cloneInstance(in Instance, name string) tea.Cmd {
+	return func() tea.Msg {
+		c, err := m.manager.Clone(in.ID, name, nil)
+		if err != nil {
+			return errMsg{err: err, retry: m.cloneInstance(in, name)}
+		}
+		return instancesChangedMsg{status: fmt.Sprintf("cloned %s into %s with id %d", in.Name, c.Name, c.ID)}
+	}
+}
+
+func (m instances) updateParams(in Instance, params map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.manager.UpdateParameters(in.ID, params)
+		if err != nil {
+			return errMsg{err: err, retry: m.updateParams(in, params)}
+		}
+		return instancesChangedMsg{status: fmt.Sprintf("redeploying %s with %d changed parameters", in.Name, len(params))}
+	}
+}
+
+// markSelected marks the items of selected instances.
+func (m instances) markSelected(items []list.Item) []list.Item {
+	marked := make([]list.Item, len(items))
+	for i, it := range items {
+		it := it.(item)
+		it.selected = m.selected[it.id]
+		marked[i] = it
+	}
+	return marked
+}
+
+// selectedInstances returns the selected instances or the instance under the
+// cursor if none is selected.
+func (m instances) selectedInstances() []Instance {
+	var ins []Instance
+	for id := range m.selected {
+		if in, ok := m.instances[id]; ok {
+			ins = append(ins, in)
+		}
+	}
+	if len(ins) == 0 {
+		if in, ok := m.instances[m.curID]; ok {
+			ins = append(ins, in)
+		}
+	}
+	sort.Slice(ins, func(i, j int) bool {
+		return ins[i].Name < ins[j].Name
+	})
+	return ins
+}
+
+func (m instances) deleteInstances(ins []Instance) tea.Cmd {
+	return func() tea.Msg {
+		results := m.manager.DeleteAll(ins, m.parallel)
+
+		var failed []BulkResult
+		for _, r := range results {
+			if r.Err != nil {
+				failed = append(failed, r)
+			}
+		}
+		if len(failed) > 0 {
+			var errs []string
+			var retry []Instance
+			for _, r := range failed {
+				errs = append(errs, r.Instance.Name+": "+r.Err.Error())
+				retry = append(retry, r.Instance)
+			}
+			err := errMsg{
+				err:   fmt.Errorf("deleted %d of %d instances, failed to delete\n%s", len(results)-len(failed), len(results), strings.Join(errs, "\n")),
+				retry: m.deleteInstances(retry),
+			}
+			if len(failed) == len(results) {
+				return err
+			}
+			// show that some instances are gone
+			return instancesChangedMsg{failed: &err}
+		}
+		return instancesChangedMsg{status: fmt.Sprintf("deleted %d instances", len(results))}
+	}
+}
+
+// showInstance shows the currently selected instance.
+func (m *instances) showInstance() {
+	in, ok := m.instances[m.curID]
+	if !ok {
+		m.setDetail("")
+		return
+	}
+	m.setDetail(renderInstance(in))
+}
+
+func (m instances) View() string {
+	if m.form.active {
+		return m.form.view()
+	}
+	if m.prompt.active {
+		return m.view() + "\n" + m.prompt.view()
+	}
+	return m.view()
+}
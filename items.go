@@ -0,0 +1,161 @@
+package instance
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// item is a list item representing a stack or an instance. It is identified
+// by its ID so that selection does not depend on the position of the item in
+// a filtered or sorted list.
+type item struct {
+	id          int
+	title, desc string
+	// filter is matched against when filtering the list. It should contain
+	// all attributes a user might want to filter by.
+	filter    string
+	createdAt time.Time
+	updatedAt time.Time
+	// expiry is the time the item expires at. The time left is shown next to
+	// the title unless it is zero.
+	expiry time.Time
+	// selected marks the item for a bulk operation.
+	selected bool
+}
+
+// expiringSoon is the time left before an item expires at which it is
+// highlighted.
+const expiringSoon = 24 * time.Hour
+
+var (
+	expiryText   = lipgloss.NewStyle().Faint(true)
+	expiringText = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	expiredText  = expiringText.Copy().Strikethrough(true)
+)
+
+func (i item) Title() string {
+	title := i.title
+	if i.selected {
+		title = "✓ " + title
+	}
+	if i.expiry.IsZero() {
+		return title
+	}
+	left := time.Until(i.expiry)
+	switch {
+	case left <= 0:
+		return expiredText.Render(title) + " " + expiringText.Render("expired")
+	case left < expiringSoon:
+		return title + " " + expiringText.Render("⏳ "+FormatDuration(left))
+	default:
+		return title + " " + expiryText.Render(FormatDuration(left))
+	}
+}
+
+func (i item) Description() string { return i.desc }
+func (i item) FilterValue() string { return i.filter }
+
+// filterValue joins the given attributes into a value to filter by.
+func filterValue(attrs ...string) string {
+	return strings.Join(attrs, " ")
+}
+
+type sortMode int
+
+const (
+	sortByName sortMode = iota
+	sortByCreated
+	sortByUpdated
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortByCreated:
+		return "created"
+	case sortByUpdated:
+		return "updated"
+	default:
+		return "name"
+	}
+}
+
+// next returns the sort mode following s.
+func (s sortMode) next() sortMode {
+	return (s + 1) % 3
+}
+
+// sortItems sorts the items by name or by newest first.
+func sortItems(items []list.Item, mode sortMode) []list.Item {
+	sorted := make([]list.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].(item), sorted[j].(item)
+		switch mode {
+		case sortByCreated:
+			return a.createdAt.After(b.createdAt)
+		case sortByUpdated:
+			return a.updatedAt.After(b.updatedAt)
+		default:
+			return strings.ToLower(a.title) < strings.ToLower(b.title)
+		}
+	})
+	return sorted
+}
+
+var listKeys = struct {
+	Sort key.Binding
+}{
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "sort by name/created/updated"),
+	),
+}
+
+// newList creates a list without title and help as these are shown by the
+// UI.
+func newList() list.Model {
+	d := list.NewDefaultDelegate()
+	d.ShowDescription = false
+
+	var items []list.Item
+	l := list.New(items, d, 0, 0)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	return l
+}
+
+// selectedID returns the ID of the selected item or -1 if none is selected.
+func selectedID(l list.Model) int {
+	it, ok := l.SelectedItem().(item)
+	if !ok {
+		return -1
+	}
+	return it.id
+}
+
+// newViewport creates a viewport for the detail pane using keys that do not
+// clash with the ones of the list.
+func newViewport() viewport.Model {
+	view := viewport.New(0, 0)
+	view.KeyMap = viewport.KeyMap{
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", " ", "f"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup", "b"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("u", "ctrl+u"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("d", "ctrl+d"),
+		),
+	}
+	return view
+}
@@ -0,0 +1,133 @@
+package instance
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortItems(t *testing.T) {
+	now := time.Now()
+	items := []list.Item{
+		item{id: 1, title: "dhis2-db", createdAt: now.Add(-2 * time.Hour), updatedAt: now},
+		item{id: 2, title: "dhis2", createdAt: now, updatedAt: now.Add(-time.Hour)},
+		item{id: 3, title: "Dhis2-core", createdAt: now.Add(-time.Hour), updatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	tests := []struct {
+		mode sortMode
+		want []int
+	}{
+		{mode: sortByName, want: []int{2, 3, 1}},
+		{mode: sortByCreated, want: []int{2, 3, 1}},
+		{mode: sortByUpdated, want: []int{1, 2, 3}},
+	}
+
+	for _, tc := range tests {
+		var got []int
+		for _, it := range sortItems(items, tc.mode) {
+			got = append(got, it.(item).id)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("sortItems(%s) mismatch (-want +got): %s\n", tc.mode, diff)
+		}
+	}
+}
+
+func TestFilteredSelectionIsShown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokens":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+		case "/stacks/":
+			fmt.Fprint(w, `[{"ID":1,"name":"dhis2"},{"ID":2,"name":"dhis2-db"},{"ID":3,"name":"dhis2-core"},{"ID":4,"name":"whoami-go"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	im := NewManager(srv.URL, "user", "pw", srv.Client())
+	var m tea.Model = NewStacks(im, DefaultLayout, nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = m.Update(m.(stacks).fetchStacks()())
+	details := map[int]*Stack{}
+	for _, st := range m.(stacks).stacks {
+		st := st
+		details[st.ID] = &st
+	}
+	m, _ = m.Update(stacksDetailsMsg{stacks: details})
+	if id := m.(stacks).curID; id != 1 {
+		t.Fatalf("selected stack %d before filtering, want 1", id)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	var cmd tea.Cmd
+	for _, r := range "dhis2-" {
+		m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m, _ = m.Update(filterMatches(t, cmd))
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+
+	got := m.(stacks)
+	if n := len(got.list.VisibleItems()); n != 2 {
+		t.Fatalf("filter matched %d stacks, want 2", n)
+	}
+	want := selectedID(got.list)
+	if want != 2 && want != 3 {
+		t.Fatalf("selected stack %d is not matched by the filter", want)
+	}
+	if got.curID != want {
+		t.Errorf("curID = %d, want the visible selected stack %d", got.curID, want)
+	}
+	if got.detail != renderStack(details[want]) {
+		t.Errorf("detail does not show the visible selected stack %d:\n%s", want, got.detail)
+	}
+}
+
+// filterMatches runs the cmd the list returns while typing a filter and
+// returns the matches of the filter.
+func filterMatches(t *testing.T, cmd tea.Cmd) list.FilterMatchesMsg {
+	t.Helper()
+	msgs := make(chan tea.Msg)
+	done := make(chan struct{})
+	defer close(done)
+	var run func(cmd tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		go func() {
+			msg := cmd()
+			// batches are unexported slices of cmds
+			if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(cmd) {
+				for i := 0; i < v.Len(); i++ {
+					if c := v.Index(i).Interface().(tea.Cmd); c != nil {
+						run(c)
+					}
+				}
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-done:
+			}
+		}()
+	}
+	run(cmd)
+	for {
+		select {
+		case msg := <-msgs:
+			if matches, ok := msg.(list.FilterMatchesMsg); ok {
+				return matches
+			}
+		case <-time.After(time.Second):
+			t.Fatal("list did not filter the items")
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by a Keyring if it holds no secret for a user.
+var ErrNotFound = errors.New("secret not found in keyring")
+
+// Keyring stores secrets of users per service.
+type Keyring interface {
+	Get(service, user string) (string, error)
+	Set(service, user, secret string) error
+	Delete(service, user string) error
+}
+
+// Keyring backends that can be passed to NewKeyring.
+const (
+	KeyringAuto          = "auto"
+	KeyringSecretService = "secret-service"
+	KeyringKeychain      = "keychain"
+	KeyringFile          = "file"
+	KeyringNone          = "none"
+)
+
+// NewKeyring returns the keyring of given backend. The auto backend uses the
+// secret service on Linux and the keychain on macOS if available and falls
+// back to a file otherwise. The none backend returns a nil Keyring.
+func NewKeyring(backend string) (Keyring, error) {
+	switch backend {
+	case KeyringNone:
+		return nil, nil
+	case KeyringSecretService:
+		return secretService{}, nil
+	case KeyringKeychain:
+		return keychain{}, nil
+	case KeyringFile:
+		return newFileKeyring()
+	case KeyringAuto, "":
+		if runtime.GOOS == "darwin" {
+			if _, err := exec.LookPath("security"); err == nil {
+				return keychain{}, nil
+			}
+		}
+		if _, err := exec.LookPath("secret-tool"); err == nil && os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+			return secretService{}, nil
+		}
+		return newFileKeyring()
+	}
+	return nil, fmt.Errorf("unknown keyring backend %q, expected one of %s", backend,
+		strings.Join([]string{KeyringAuto, KeyringSecretService, KeyringKeychain, KeyringFile, KeyringNone}, ", "))
+}
+
+// secretService stores secrets in the freedesktop secret service like GNOME
+// Keyring or KWallet using secret-tool.
+type secretService struct{}
+
+func (secretService) Get(service, user string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "user", user).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) == 0 {
+			// secret-tool exits with 1 without output if nothing is found
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret-tool lookup failed: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (secretService) Set(service, user, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" ("+user+")", "service", service, "user", user)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool store failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (secretService) Delete(service, user string) error {
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "user", user).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool clear failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// keychain stores secrets in the macOS keychain using security.
+type keychain struct{}
+
+func (keychain) Get(service, user string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", user, "-w").Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && ee.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("security find-generic-password failed: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (keychain) Set(service, user, secret string) error {
+	// pass the command via stdin so the secret does not show up in the
+	// process list
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quote(service), quote(user), quote(secret)))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("security add-generic-password failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (keychain) Delete(service, user string) error {
+	if out, err := exec.Command("security", "delete-generic-password", "-s", service, "-a", user).CombinedOutput(); err != nil {
+		return fmt.Errorf("security delete-generic-password failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// quote quotes s for the command line of security -i.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// fileKeyring stores secrets in a JSON file only readable by the user. It is
+// meant for headless machines without a secret service. Secrets are stored
+// unencrypted so the file must be protected like an SSH key.
+type fileKeyring struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newFileKeyring() (*fileKeyring, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &fileKeyring{path: filepath.Join(dir, "dhis2-im", "credentials.json")}, nil
+}
+
+// secrets maps services to users to secrets.
+type secrets map[string]map[string]string
+
+func (k *fileKeyring) read() (secrets, error) {
+	b, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := secrets{}
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("reading keyring %s failed: %w", k.path, err)
+	}
+	return s, nil
+}
+
+func (k *fileKeyring) write(s secrets) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, b, 0o600)
+}
+
+func (k *fileKeyring) Get(service, user string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s, err := k.read()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := s[service][user]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k *fileKeyring) Set(service, user, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s, err := k.read()
+	if err != nil {
+		return err
+	}
+	if s[service] == nil {
+		s[service] = make(map[string]string)
+	}
+	s[service][user] = secret
+	return k.write(s)
+}
+
+func (k *fileKeyring) Delete(service, user string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	s, err := k.read()
+	if err != nil {
+		return err
+	}
+	delete(s[service], user)
+	if len(s[service]) == 0 {
+		delete(s, service)
+	}
+	return k.write(s)
+}
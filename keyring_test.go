@@ -0,0 +1,40 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileKeyring(t *testing.T) {
+	k := &fileKeyring{path: filepath.Join(t.TempDir(), "dhis2-im", "credentials.json")}
+
+	if _, err := k.Get("im", "user"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get on empty keyring returned %v, want ErrNotFound", err)
+	}
+	if err := k.Set("im", "user", "secret"); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	got, err := k.Get("im", "user")
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	if got != "secret" {
+		t.Errorf("Get = %q, want %q", got, "secret")
+	}
+	fi, err := os.Stat(k.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("keyring file has permissions %o, want 600", perm)
+	}
+
+	if err := k.Delete("im", "user"); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if _, err := k.Get("im", "user"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete returned %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,61 @@
+package instance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Layout divides the space available to a component between its list pane
+// and its detail pane.
+type Layout struct {
+	// ListRatio and DetailRatio are the relative widths of the list and the
+	// detail pane. A ratio of 1:2 gives the detail pane twice the width of
+	// the list.
+	ListRatio   int
+	DetailRatio int
+	// CollapseWidth is the width below which the detail pane is collapsed so
+	// that only one pane is shown at a time.
+	CollapseWidth int
+}
+
+// DefaultLayout gives the detail pane twice the width of the list and
+// collapses it on terminals narrower than 80 columns.
+var DefaultLayout = Layout{
+	ListRatio:     1,
+	DetailRatio:   2,
+	CollapseWidth: 80,
+}
+
+// ParseRatio parses a ratio like "1:2" into the list and detail ratio of the
+// layout.
+func ParseRatio(s string) (list, detail int, err error) {
+	l, d, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid ratio %q: expected format list:detail like 1:2", s)
+	}
+	list, err = strconv.Atoi(strings.TrimSpace(l))
+	if err != nil || list <= 0 {
+		return 0, 0, fmt.Errorf("invalid ratio %q: list ratio must be a positive number", s)
+	}
+	detail, err = strconv.Atoi(strings.TrimSpace(d))
+	if err != nil || detail <= 0 {
+		return 0, 0, fmt.Errorf("invalid ratio %q: detail ratio must be a positive number", s)
+	}
+	return list, detail, nil
+}
+
+type pane struct {
+	width, height int
+}
+
+// split divides given width and height into a list and a detail pane. The
+// detail pane has a width of 0 if it is collapsed.
+func (l Layout) split(width, height int) (list, detail pane) {
+	if width < l.CollapseWidth || l.ListRatio+l.DetailRatio <= 0 {
+		return pane{width: width, height: height}, pane{height: height}
+	}
+
+	lw := width * l.ListRatio / (l.ListRatio + l.DetailRatio)
+	return pane{width: lw, height: height}, pane{width: width - lw, height: height}
+}
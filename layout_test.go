@@ -0,0 +1,37 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLayoutSplit(t *testing.T) {
+	l := Layout{ListRatio: 1, DetailRatio: 2, CollapseWidth: 80}
+
+	list, detail := l.split(120, 40)
+	if diff := cmp.Diff([]pane{{width: 40, height: 40}, {width: 80, height: 40}}, []pane{list, detail}, cmp.AllowUnexported(pane{})); diff != "" {
+		t.Errorf("split(120, 40) mismatch (-want +got): %s\n", diff)
+	}
+
+	list, detail = l.split(60, 40)
+	if diff := cmp.Diff([]pane{{width: 60, height: 40}, {width: 0, height: 40}}, []pane{list, detail}, cmp.AllowUnexported(pane{})); diff != "" {
+		t.Errorf("split(60, 40) mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestParseRatio(t *testing.T) {
+	list, detail, err := ParseRatio("1:3")
+	if err != nil {
+		t.Fatalf("ParseRatio(1:3) failed: %s", err)
+	}
+	if list != 1 || detail != 3 {
+		t.Errorf("ParseRatio(1:3) = %d:%d, want 1:3", list, detail)
+	}
+
+	for _, in := range []string{"1", "0:1", "a:2", "1:-2"} {
+		if _, _, err := ParseRatio(in); err == nil {
+			t.Errorf("ParseRatio(%s) expected an error", in)
+		}
+	}
+}
@@ -2,129 +2,689 @@ package instance
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Manager struct {
 	url    string
 	user   string
-	pw     string
+	auth   Authenticator
 	client *http.Client
-	token  string
+
+	// renewMu serializes renewing the access token so that concurrent
+	// requests renew it only once.
+	renewMu     sync.Mutex
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	latency     time.Duration
 }
 
+// NewManager returns a manager authenticating with the email and password of
+// a user.
 func NewManager(URL, user, pw string, client *http.Client) *Manager {
+	return NewManagerWithAuth(URL, user, BasicAuth{User: user, Password: pw}, client)
+}
+
+// NewManagerWithAuth returns a manager obtaining its access token from given
+// authenticator. The user is only used for display and may be empty.
+func NewManagerWithAuth(URL, user string, auth Authenticator, client *http.Client) *Manager {
 	return &Manager{
 		url:    URL,
 		user:   user,
-		pw:     pw,
+		auth:   auth,
 		client: client,
 	}
 }
 
-type tokenBody struct {
-	Token string `json:"access_token"`
+// Error is returned if the instance manager responds with an unexpected HTTP
+// status. Message holds the explanation sent by the instance manager, if any.
+type Error struct {
+	Op         string
+	Want       int
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s failed: expected HTTP status %d, got %s", e.Op, e.Want, e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// maxErrorBody is the maximum number of bytes read from an error response.
+const maxErrorBody = 4 << 10
+
+func newError(op string, want int, resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	return &Error{
+		Op:         op,
+		Want:       want,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Message:    errorMessage(b),
+	}
+}
+
+// errorMessage extracts the explanation from an error response body. The
+// instance manager either responds with plain text or with a JSON object
+// containing the message.
+func errorMessage(b []byte) string {
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(b, &body); err == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Error != "" {
+			return body.Error
+		}
+	}
+	return strings.TrimSpace(string(b))
 }
 
+// Login obtains an access token using the authenticator of the manager.
 func (m *Manager) Login() error {
-	req, err := http.NewRequest(http.MethodPost, m.url+"/tokens", nil)
+	t, err := m.auth.Authenticate(m.client, m.url)
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth(m.user, m.pw)
+	m.setToken(t)
+	return nil
+}
+
+func (m *Manager) setToken(t *Token) {
+	m.mu.Lock()
+	m.token = t.Access
+	m.tokenExpiry = t.Expiry
+	m.mu.Unlock()
+}
+
+// expirySkew is how long before its expiry an access token is renewed.
+const expirySkew = 30 * time.Second
+
+// renew obtains a new access token using the authenticator of the manager
+// if the current one is about to expire. Requests waiting for a renewal in
+// progress use the renewed token.
+func (m *Manager) renew() error {
+	if !m.expiring() {
+		return nil
+	}
+	m.renewMu.Lock()
+	defer m.renewMu.Unlock()
+	if !m.expiring() {
+		return nil
+	}
+	return m.Login()
+}
+
+// expiring reports whether the access token is about to expire.
+func (m *Manager) expiring() bool {
+	m.mu.Lock()
+	expiry := m.tokenExpiry
+	m.mu.Unlock()
+	return !expiry.IsZero() && time.Until(expiry) <= expirySkew
+}
+
+// do sends the request adding the access token if Login() was called. The
+// token is renewed before it expires. The duration of the round trip is kept
+// as the latency of the last request unless the cache served the response.
+func (m *Manager) do(req *http.Request) (*http.Response, error) {
+	if err := m.renew(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	token := m.token
+	m.mu.Unlock()
+	if token != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+
+	// responses served from the cache say nothing about the latency
+	var hit bool
+	req = req.WithContext(withCacheHit(req.Context(), &hit))
+	start := time.Now()
 	resp, err := m.client.Do(req)
+	if !hit {
+		m.mu.Lock()
+		m.latency = time.Since(start)
+		m.mu.Unlock()
+	}
+
+	return resp, err
+}
+
+// Host returns the host of the instance manager URL.
+func (m *Manager) Host() string {
+	u, err := url.Parse(m.url)
+	if err != nil || u.Host == "" {
+		return m.url
+	}
+	return u.Host
+}
+
+// User returns the user the manager performs actions as. It is empty if the
+// manager authenticates with a token.
+func (m *Manager) User() string {
+	return m.user
+}
+
+// TokenExpiry returns the time the access token expires at. The zero time is
+// returned if the user is not logged in or the expiry is unknown.
+func (m *Manager) TokenExpiry() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tokenExpiry
+}
+
+// Latency returns the duration of the last request sent to the instance
+// manager.
+func (m *Manager) Latency() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latency
+}
+
+type User struct {
+	ID          int     `json:"ID"`
+	Email       string  `json:"Email"`
+	Groups      []Group `json:"Groups"`
+	AdminGroups []Group `json:"AdminGroups"`
+}
+
+// Me returns the user that is logged in.
+func (m *Manager) Me() (*User, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url+"/me", nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("login failed: expected HTTP status 201, got %s", resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching user", http.StatusOK, resp)
 	}
 
 	d := json.NewDecoder(resp.Body)
-	tb := &tokenBody{}
-	if err := d.Decode(tb); err != nil {
-		return err
-	}
-
-	if tb.Token == "" {
-		return errors.New("login failed: token is empty")
+	u := &User{}
+	if err := d.Decode(u); err != nil {
+		return nil, err
 	}
-	m.token = tb.Token
 
-	return nil
+	return u, nil
 }
 
 type createBody struct {
-	Name    string `json:"name"`
-	GroupID int    `json:"groupId"`
-	StackID int    `json:"stackID"`
+	Name           string          `json:"name"`
+	GroupID        int             `json:"groupId"`
+	StackID        int             `json:"stackID"`
+	RequiredParams []InstanceParam `json:"requiredParameters,omitempty"`
+	OptionalParams []InstanceParam `json:"optionalParameters,omitempty"`
 }
 
-// TODO the parameters are probably interesting/worth getting
-type createRespBody struct {
-	ID      int    `json:"ID"`
-	Name    string `json:"name"`
-	GroupID int    `json:"groupId"`
-	StackID int    `json:"stackID"`
-}
+// Create creates an instance of the stack in the group. The params are
+// assigned to the required and optional parameters of the stack. An error is
+// returned if a required parameter is missing or a parameter is not defined
+// by the stack.
+func (m *Manager) Create(name string, group, stack int, params map[string]string) (*Instance, error) {
+	st, err := m.Stack(stack)
+	if err != nil {
+		return nil, err
+	}
+	required, optional, err := st.assign(params)
+	if err != nil {
+		return nil, err
+	}
 
-func (m *Manager) Create(name string, group, stack int) error {
 	c := &createBody{
-		Name:    name,
-		GroupID: group,
-		StackID: stack,
+		Name:           name,
+		GroupID:        group,
+		StackID:        stack,
+		RequiredParams: required,
+		OptionalParams: optional,
 	}
 	b, err := json.Marshal(c)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	req, err := http.NewRequest(http.MethodPost, m.url+"/instances", bytes.NewBuffer(b))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// TODO assumes Login() was called/and token is not expired
-	req.Header.Add("Authorization", "Bearer "+m.token)
 	req.Header.Add("Content-Type", "application/json")
-	resp, err := m.client.Do(req)
+	resp, err := m.do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("create failed: expected HTTP status 201, got %s", resp.Status)
+		return nil, newError("create", http.StatusCreated, resp)
 	}
 
 	d := json.NewDecoder(resp.Body)
-	cb := &createRespBody{}
-	if err := d.Decode(cb); err != nil {
+	in := &Instance{}
+	if err := d.Decode(in); err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// Clone creates an instance named newName in the group of the instance with
+// given id. The new instance uses the same stack and parameter values as the
+// source instance. The overrides replace or add parameter values.
+func (m *Manager) Clone(id int, newName string, overrides map[string]string) (*Instance, error) {
+	return m.CloneToGroup(id, newName, 0, overrides)
+}
+
+// CloneToGroup clones the instance like Clone but creates the new instance in
+// the given group. A group of 0 means the group of the source instance.
+func (m *Manager) CloneToGroup(id int, newName string, group int, overrides map[string]string) (*Instance, error) {
+	src, err := m.Instance(id)
+	if err != nil {
+		return nil, err
+	}
+	if group == 0 {
+		group = src.GroupID
+	}
+
+	params := src.Params()
+	for k, v := range overrides {
+		params[k] = v
+	}
+
+	return m.Create(newName, group, src.StackID, params)
+}
+
+// Delete deletes the instance.
+func (m *Manager) Delete(id int) error {
+	req, err := http.NewRequest(http.MethodDelete, m.url+"/instances/"+strconv.Itoa(id), nil)
+	if err != nil {
 		return err
 	}
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return newError("delete", http.StatusAccepted, resp)
+	}
 
 	return nil
 }
 
-type OptionalParam struct {
-	ID           int    `json:"ID"`
-	Name         string `json:"Name"`
-	DefaultValue string `json:"DefaultValue"`
+// Restart restarts the instance.
+func (m *Manager) Restart(id int) error {
+	req, err := http.NewRequest(http.MethodPut, m.url+"/instances/"+strconv.Itoa(id)+"/restart", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return newError("restart", http.StatusAccepted, resp)
+	}
+
+	return nil
 }
 
+const (
+	// StatusRunning is the status of an instance that is deployed and ready.
+	StatusRunning = "Running"
+)
+
+// WaitUntilRunning polls the instance in given interval until its status is
+// StatusRunning. An error is returned if the deployment failed or the
+// context is done before.
+func (m *Manager) WaitUntilRunning(ctx context.Context, id int, interval time.Duration) (*Instance, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		in, err := m.Instance(id)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(in.Status, StatusRunning) {
+			return in, nil
+		}
+		if s := strings.ToLower(in.Status); strings.Contains(s, "error") || strings.Contains(s, "fail") {
+			return nil, fmt.Errorf("instance %q failed to deploy: status is %s", in.Name, in.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for instance %q to run: %w", in.Name, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// RequiredParam is a parameter of a stack that must be given when creating
+// an instance.
 type RequiredParam struct {
-	ID   int    `json:"ID"`
-	Name string `json:"Name"`
+	ID        int        `json:"ID"`
+	CreatedAt time.Time  `json:"CreatedAt"`
+	UpdatedAt time.Time  `json:"UpdatedAt"`
+	DeletedAt *time.Time `json:"DeletedAt"`
+	StackID   int        `json:"StackID"`
+	Name      string     `json:"Name"`
 }
 
-// TODO Instances? and parameters
+// OptionalParam is a parameter of a stack that defaults to DefaultValue.
+type OptionalParam struct {
+	ID           int        `json:"ID"`
+	CreatedAt    time.Time  `json:"CreatedAt"`
+	UpdatedAt    time.Time  `json:"UpdatedAt"`
+	DeletedAt    *time.Time `json:"DeletedAt"`
+	StackID      int        `json:"StackID"`
+	Name         string     `json:"Name"`
+	DefaultValue string     `json:"DefaultValue"`
+}
+
+type Instance struct {
+	ID        int       `json:"ID"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+	UserID    int       `json:"UserID"`
+	Name      string    `json:"Name"`
+	GroupID   int       `json:"GroupID"`
+	GroupName string    `json:"GroupName"`
+	// Hostname is the hostname of the group the instance is deployed to.
+	Hostname       string          `json:"Hostname"`
+	StackID        int             `json:"StackID"`
+	Status         string          `json:"Status"`
+	RequiredParams []InstanceParam `json:"RequiredParameters"`
+	OptionalParams []InstanceParam `json:"OptionalParameters"`
+}
+
+type InstanceParam struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// ttlParam is the name of the optional parameter holding the time to live of
+// an instance in seconds.
+const ttlParam = "INSTANCE_TTL"
+
+// Param returns the value of the required or optional parameter with given
+// name.
+func (in Instance) Param(name string) (string, bool) {
+	for _, p := range in.RequiredParams {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	for _, p := range in.OptionalParams {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Params returns the values of the required and optional parameters of the
+// instance by name.
+func (in Instance) Params() map[string]string {
+	params := make(map[string]string)
+	for _, p := range in.RequiredParams {
+		params[p.Name] = p.Value
+	}
+	for _, p := range in.OptionalParams {
+		params[p.Name] = p.Value
+	}
+	return params
+}
+
+// TTL returns the time to live of the instance. It returns 0 if the instance
+// does not expire.
+func (in Instance) TTL() time.Duration {
+	v, ok := in.Param(ttlParam)
+	if !ok {
+		return 0
+	}
+	sec, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || sec <= 0 {
+		return 0
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// Expiry returns the time the instance expires at. The zero time is returned
+// if the instance does not expire.
+func (in Instance) Expiry() time.Time {
+	ttl := in.TTL()
+	if ttl == 0 {
+		return time.Time{}
+	}
+	return in.CreatedAt.Add(ttl)
+}
+
+// Instance returns the instance with given id.
+func (m *Manager) Instance(id int) (*Instance, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url+"/instances/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching instance", http.StatusOK, resp)
+	}
+
+	d := json.NewDecoder(resp.Body)
+	in := &Instance{}
+	if err := d.Decode(in); err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// InstanceByName returns the instance with given name. An error is returned
+// if no or more than one instance in different groups has that name.
+func (m *Manager) InstanceByName(name string) (*Instance, error) {
+	ins, err := m.Instances()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Instance
+	for _, in := range ins {
+		if in.Name == name {
+			found = append(found, in)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("instance %q not found", name)
+	case 1:
+		return &found[0], nil
+	}
+	var groups []string
+	for _, in := range found {
+		groups = append(groups, in.GroupName)
+	}
+	return nil, fmt.Errorf("instance name %q is ambiguous: found it in groups %s", name, strings.Join(groups, ", "))
+}
+
+// InstanceURL returns the public URL of the instance. The instance is served
+// by the host of its group using the instance name as path.
+func (m *Manager) InstanceURL(in *Instance) (string, error) {
+	host := in.Hostname
+	if host == "" {
+		u, err := m.Me()
+		if err != nil {
+			return "", err
+		}
+		for _, g := range append(u.Groups, u.AdminGroups...) {
+			if g.Name == in.GroupName {
+				host = g.Hostname
+				break
+			}
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("hostname of group %q of instance %q is unknown", in.GroupName, in.Name)
+	}
+
+	u := url.URL{
+		Scheme: "https",
+		Host:   host,
+		Path:   "/" + in.Name,
+	}
+	return u.String(), nil
+}
+
+type ttlBody struct {
+	TTL int `json:"ttl"`
+}
+
+// ExtendTTL extends the time to live of the instance by given duration. It
+// returns the time the instance expires at after the extension.
+func (m *Manager) ExtendTTL(id int, by time.Duration) (time.Time, error) {
+	if by <= 0 {
+		return time.Time{}, fmt.Errorf("extending TTL failed: duration must be positive, got %s", by)
+	}
+	in, err := m.Instance(id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ttl := in.TTL()
+	if ttl == 0 {
+		return time.Time{}, fmt.Errorf("extending TTL failed: instance %q does not expire", in.Name)
+	}
+	ttl += by
+
+	b, err := json.Marshal(&ttlBody{TTL: int(ttl.Seconds())})
+	if err != nil {
+		return time.Time{}, err
+	}
+	req, err := http.NewRequest(http.MethodPut, m.url+"/instances/"+strconv.Itoa(id)+"/ttl", bytes.NewBuffer(b))
+	if err != nil {
+		return time.Time{}, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := m.do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return time.Time{}, newError("extending TTL", http.StatusNoContent, resp)
+	}
+
+	return in.CreatedAt.Add(ttl), nil
+}
+
+type groupInstances struct {
+	Name      string     `json:"Name"`
+	Hostname  string     `json:"Hostname"`
+	Instances []Instance `json:"Instances"`
+}
+
+// Instances returns the instances of all groups the user is a member of.
+func (m *Manager) Instances() ([]Instance, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url+"/instances", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching instances", http.StatusOK, resp)
+	}
+
+	d := json.NewDecoder(resp.Body)
+	var gis []groupInstances
+	if err := d.Decode(&gis); err != nil {
+		return nil, err
+	}
+
+	var ins []Instance
+	for _, gi := range gis {
+		for _, in := range gi.Instances {
+			if in.GroupName == "" {
+				in.GroupName = gi.Name
+			}
+			if in.Hostname == "" {
+				in.Hostname = gi.Hostname
+			}
+			ins = append(ins, in)
+		}
+	}
+
+	return ins, nil
+}
+
+// Stack is a template instances are created from. The parameters are only
+// returned when fetching a single stack.
 type Stack struct {
 	ID             int             `json:"ID"`
+	CreatedAt      time.Time       `json:"CreatedAt"`
+	UpdatedAt      time.Time       `json:"UpdatedAt"`
+	DeletedAt      *time.Time      `json:"DeletedAt"`
 	Name           string          `json:"name"`
-	OptionalParams []OptionalParam `json:"optionalParameters"`
-	RequiredParams []RequiredParam `json:"requiredParameters"`
+	RequiredParams []RequiredParam `json:"requiredParameters,omitempty"`
+	OptionalParams []OptionalParam `json:"optionalParameters,omitempty"`
+	Instances      []Instance      `json:"Instances"`
+}
+
+// assign assigns the params to the required and optional parameters of the
+// stack.
+func (st *Stack) assign(params map[string]string) (required, optional []InstanceParam, err error) {
+	assigned := make(map[string]bool)
+	for _, p := range st.RequiredParams {
+		v, ok := params[p.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("stack %q requires parameter %s", st.Name, p.Name)
+		}
+		required = append(required, InstanceParam{Name: p.Name, Value: v})
+		assigned[p.Name] = true
+	}
+	for _, p := range st.OptionalParams {
+		if v, ok := params[p.Name]; ok {
+			optional = append(optional, InstanceParam{Name: p.Name, Value: v})
+			assigned[p.Name] = true
+		}
+	}
+	var unknown []string
+	for name := range params {
+		if !assigned[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, nil, fmt.Errorf("stack %q has no parameters %s", st.Name, strings.Join(unknown, ", "))
+	}
+	return required, optional, nil
 }
 
 func (m *Manager) Stack(id int) (*Stack, error) {
@@ -132,15 +692,13 @@ func (m *Manager) Stack(id int) (*Stack, error) {
 	if err != nil {
 		return nil, err
 	}
-	// TODO assumes Login() was called/and token is not expired
-	req.Header.Add("Authorization", "Bearer "+m.token)
-	resp, err := m.client.Do(req)
+	resp, err := m.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetching stack failed: expected HTTP status 200, got %s", resp.Status)
+		return nil, newError("fetching stack", http.StatusOK, resp)
 	}
 
 	d := json.NewDecoder(resp.Body)
@@ -165,29 +723,52 @@ func (m *Manager) StackDetails(ids ...int) ([]*Stack, error) {
 	return sts, nil
 }
 
-type Stacks struct {
-	ID   int    `json:"ID"`
-	Name string `json:"name"`
+// StackInstances returns the instances in the groups of the user per ID of
+// the stack they are deployed from. Use it instead of Stack.Instances as the
+// instance manager does not send the instances of stacks.
+func (m *Manager) StackInstances() (map[int][]Instance, error) {
+	ins, err := m.Instances()
+	if err != nil {
+		return nil, err
+	}
+	byStack := make(map[int][]Instance)
+	for _, in := range ins {
+		byStack[in.StackID] = append(byStack[in.StackID], in)
+	}
+	return byStack, nil
 }
 
-func (m *Manager) Stacks() ([]Stacks, error) {
+// StackByName returns the stack with given name.
+func (m *Manager) StackByName(name string) (*Stack, error) {
+	sts, err := m.Stacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range sts {
+		if st.Name == name {
+			return m.Stack(st.ID)
+		}
+	}
+	return nil, fmt.Errorf("stack %q not found", name)
+}
+
+// Stacks returns all stacks without their parameters.
+func (m *Manager) Stacks() ([]Stack, error) {
 	req, err := http.NewRequest(http.MethodGet, m.url+"/stacks/", nil)
 	if err != nil {
 		return nil, err
 	}
-	// TODO assumes Login() was called/and token is not expired
-	req.Header.Add("Authorization", "Bearer "+m.token)
-	resp, err := m.client.Do(req)
+	resp, err := m.do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetching stacks failed: expected HTTP status 200, got %s", resp.Status)
+		return nil, newError("fetching stacks", http.StatusOK, resp)
 	}
 
 	d := json.NewDecoder(resp.Body)
-	var sts []Stacks
+	var sts []Stack
 	if err := d.Decode(&sts); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,109 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestErrorContainsServerExplanation(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: "record not found\n", want: "record not found"},
+		{body: `{"message":"stack not found"}`, want: "stack not found"},
+		{body: `{"error":"invalid token"}`, want: "invalid token"},
+		{body: "", want: ""},
+	}
+
+	for _, tc := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, tc.body)
+		}))
+		m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+		_, err := m.Stack(1)
+		srv.Close()
+
+		e, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("Stack(1) returned %T, want *Error", err)
+		}
+		if diff := cmp.Diff(tc.want, e.Message); diff != "" {
+			t.Errorf("Message mismatch for body %q (-want +got): %s\n", tc.body, diff)
+		}
+		if e.StatusCode != http.StatusNotFound {
+			t.Errorf("StatusCode = %d, want %d", e.StatusCode, http.StatusNotFound)
+		}
+	}
+}
+
+func TestStackDecodesAllFields(t *testing.T) {
+	b, err := os.ReadFile("mockup/stacks/stack.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var st Stack
+	if err := json.Unmarshal(b, &st); err != nil {
+		t.Fatalf("failed to decode stack: %s", err)
+	}
+
+	if st.Name != "dhis2" || st.CreatedAt.IsZero() || st.UpdatedAt.IsZero() || st.DeletedAt != nil {
+		t.Errorf("stack attributes not decoded: %+v", st)
+	}
+	want := RequiredParam{
+		ID:        1,
+		CreatedAt: time.Date(2022, 5, 11, 11, 15, 37, 152658000, time.UTC),
+		UpdatedAt: time.Date(2022, 5, 11, 11, 15, 37, 152658000, time.UTC),
+		StackID:   1,
+		Name:      "DATABASE_ID",
+	}
+	if diff := cmp.Diff(want, st.RequiredParams[0]); diff != "" {
+		t.Errorf("RequiredParams[0] mismatch (-want +got): %s\n", diff)
+	}
+	for _, p := range st.OptionalParams {
+		if p.StackID != st.ID {
+			t.Errorf("optional parameter %s has StackID %d, want %d", p.Name, p.StackID, st.ID)
+		}
+	}
+}
+
+func TestStackInstances(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/instances" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"Name":"play","Instances":[{"ID":1,"Name":"a","StackID":2},{"ID":2,"Name":"b","StackID":3}]},
+			{"Name":"dev","Instances":[{"ID":3,"Name":"c","StackID":2}]}
+		]`))
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+	got, err := m.StackInstances()
+	if err != nil {
+		t.Fatalf("StackInstances failed: %s", err)
+	}
+
+	names := make(map[int][]string)
+	for id, ins := range got {
+		for _, in := range ins {
+			names[id] = append(names[id], in.Name)
+		}
+	}
+	want := map[int][]string{2: {"a", "c"}, 3: {"b"}}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("StackInstances mismatch (-want +got): %s\n", diff)
+	}
+}
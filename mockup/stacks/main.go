@@ -120,7 +120,7 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	var stacks []instance.Stacks
+	var stacks []instance.Stack
 	err = json.Unmarshal(sts, &stacks)
 	if err != nil {
 		return err
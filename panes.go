@@ -0,0 +1,143 @@
+package instance
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var paneKeys = struct {
+	TogglePane key.Binding
+}{
+	TogglePane: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "toggle list/detail"),
+	),
+}
+
+// panes shows a list next to a viewport showing the details of the selected
+// item. The detail pane is collapsed according to the layout, in which case
+// only one of the panes is shown at a time.
+type panes struct {
+	layout     Layout
+	list       list.Model
+	viewport   viewport.Model
+	collapsed  bool
+	showDetail bool
+	curID      int
+	detail     string
+	width      int
+	height     int
+	// reserved is the number of lines below the panes used by the component
+	// for other content like a prompt.
+	reserved int
+}
+
+func newPanes(layout Layout) panes {
+	return panes{
+		layout:   layout,
+		list:     newList(),
+		viewport: newViewport(),
+		curID:    -1,
+	}
+}
+
+// update passes the message on to the list and the viewport. It reports
+// whether the selected item changed.
+func (p *panes) update(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if p.collapsed && !p.filtering() && key.Matches(msg, paneKeys.TogglePane) {
+			p.showDetail = !p.showDetail
+			return nil, false
+		}
+	case tea.WindowSizeMsg:
+		p.resize(msg.Width, msg.Height)
+		return nil, false
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	cmds = append(cmds, cmd)
+	p.viewport, cmd = p.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...), p.selectionChanged()
+}
+
+// filtering reports whether the user is currently typing a filter.
+func (p panes) filtering() bool {
+	return p.list.FilterState() == list.Filtering
+}
+
+// setItems sets the items sorted by given mode. It reports whether the
+// selected item changed.
+func (p *panes) setItems(items []list.Item, mode sortMode) (tea.Cmd, bool) {
+	cmd := p.list.SetItems(sortItems(items, mode))
+	return cmd, p.selectionChanged()
+}
+
+// selectionChanged updates the ID of the selected item. Items are tracked by
+// their ID as the position of an item changes when the list is filtered or
+// sorted.
+func (p *panes) selectionChanged() bool {
+	id := selectedID(p.list)
+	if id == p.curID {
+		return false
+	}
+	p.curID = id
+	p.viewport.GotoTop()
+	return true
+}
+
+func (p *panes) setDetail(detail string) {
+	if detail == p.detail {
+		return
+	}
+	p.detail = detail
+	p.viewport.SetContent(detail)
+}
+
+// reserve reserves given number of lines below the panes.
+func (p *panes) reserve(lines int) {
+	p.reserved = lines
+	p.resize(p.width, p.height)
+}
+
+// resize splits given space between the list and the viewport according to
+// the layout.
+func (p *panes) resize(width, height int) {
+	p.width, p.height = width, height
+	h, v := docStyle.GetFrameSize()
+	lp, dp := p.layout.split(width, max(0, height-p.reserved))
+	p.collapsed = dp.width == 0
+	if p.collapsed {
+		dp.width = width
+	}
+
+	p.list.SetSize(max(0, lp.width-h), max(0, lp.height-v))
+	p.viewport.Width = max(0, dp.width-h)
+	p.viewport.Height = max(0, dp.height-v)
+}
+
+func (p panes) view() string {
+	if p.collapsed {
+		if p.showDetail {
+			return docStyle.Render(p.viewport.View())
+		}
+		return docStyle.Render(p.list.View())
+	}
+
+	list := docStyle.Render(p.list.View())
+	if p.detail == "" {
+		return list
+	}
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		list,
+		docStyle.Render(p.viewport.View()),
+	)
+}
@@ -0,0 +1,94 @@
+package instance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// KeyringService returns the name under which credentials for the instance
+// manager at given URL are stored in a keyring.
+func KeyringService(url string) string {
+	return "dhis2-im-manager:" + strings.TrimSuffix(url, "/")
+}
+
+// PasswordSource describes where the password of a user is read from. The
+// sources are tried in the order of the fields. The user is prompted for the
+// password if none of the sources provide one.
+type PasswordSource struct {
+	// Password is given directly for example via a flag.
+	Password string
+	// File is the path to a file containing the password. A path of "-"
+	// reads the password from stdin.
+	File string
+	// Keyring holds passwords saved for the Service and User.
+	Keyring Keyring
+	Service string
+	User    string
+	// NoPrompt fails instead of prompting for the password.
+	NoPrompt bool
+}
+
+// Read reads the password from the first source providing one. It only
+// prompts the user if stdin is a terminal and NoPrompt is false.
+func (s PasswordSource) Read() (string, error) {
+	if s.Password != "" {
+		return s.Password, nil
+	}
+	if s.File != "" {
+		return readPasswordFile(s.File)
+	}
+	if s.Keyring != nil {
+		pw, err := s.Keyring.Get(s.Service, s.User)
+		if err == nil {
+			return pw, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+	}
+
+	fd := int(os.Stdin.Fd())
+	if s.NoPrompt || !term.IsTerminal(fd) {
+		return "", errors.New("password is required: pass it via a file or stdin when not running in a terminal")
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", s.User)
+	pw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(pw) == 0 {
+		return "", errors.New("password is required")
+	}
+	return string(pw), nil
+}
+
+// readPasswordFile reads the first line of the file or stdin if the path is
+// "-".
+func readPasswordFile(path string) (string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	pw, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	pw = strings.TrimRight(pw, "\r\n")
+	if pw == "" {
+		return "", fmt.Errorf("file %s is empty", path)
+	}
+	return pw, nil
+}
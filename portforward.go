@@ -0,0 +1,143 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// PortForward forwards connections accepted on the listener to the port of
+// the service of the instance. The instance manager proxies each connection
+// to the service over an upgraded HTTP connection. PortForward blocks until
+// the context is done or the listener fails. The listener is closed on
+// return.
+func (m *Manager) PortForward(ctx context.Context, id int, service string, port int, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			// errors are not returned as they only concern this connection
+			_ = m.forward(ctx, id, service, port, conn)
+		}()
+	}
+}
+
+// forward proxies the connection to the port of the service of the instance
+// until either side closes its connection.
+func (m *Manager) forward(ctx context.Context, id int, service string, port int, conn net.Conn) error {
+	q := url.Values{}
+	q.Set("service", service)
+	q.Set("port", strconv.Itoa(port))
+	u := m.url + "/instances/" + strconv.Itoa(id) + "/port-forward?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return newError("port-forward", http.StatusSwitchingProtocols, resp)
+	}
+	remote, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return errors.New("port-forward failed: connection cannot be upgraded")
+	}
+
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(remote, conn)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, remote)
+		errc <- err
+	}()
+
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+	}
+	return err
+}
+
+// DBConnection holds the details needed to connect to the database of an
+// instance. The host and port are those of a local port-forward.
+type DBConnection struct {
+	Host     string
+	Port     int
+	Database string
+	Username string
+	Password string
+}
+
+const (
+	// DBService is the name of the database service of an instance.
+	DBService = "database"
+	// DBPort is the port the database service listens on.
+	DBPort = 5432
+)
+
+// DBConnection returns the database name and credentials of the instance.
+// Parameters the instance does not set are taken from the defaults of its
+// stack.
+func (m *Manager) DBConnection(id int) (*DBConnection, error) {
+	in, err := m.Instance(id)
+	if err != nil {
+		return nil, err
+	}
+	st, err := m.Stack(in.StackID)
+	if err != nil {
+		return nil, err
+	}
+
+	param := func(name string) (string, error) {
+		if v, ok := in.Param(name); ok && v != "" {
+			return v, nil
+		}
+		for _, p := range st.OptionalParams {
+			if p.Name == name && p.DefaultValue != "" {
+				return p.DefaultValue, nil
+			}
+		}
+		return "", fmt.Errorf("instance %q has no value for parameter %s", in.Name, name)
+	}
+	c := &DBConnection{}
+	if c.Database, err = param("DATABASE_NAME"); err != nil {
+		return nil, err
+	}
+	if c.Username, err = param("DATABASE_USERNAME"); err != nil {
+		return nil, err
+	}
+	if c.Password, err = param("DATABASE_PASSWORD"); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,63 @@
+package instance
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPortForward(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/instances/1/port-forward" || r.URL.Query().Get("service") != "database" || r.URL.Query().Get("port") != "5432" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack connection: %s", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		rw.Flush()
+		// echo what is sent through the forwarded connection
+		io.Copy(conn, rw)
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- m.PortForward(ctx, 1, DBService, DBPort, l)
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "ping\n" {
+		t.Errorf("got %q through forwarded connection, want %q", got, "ping\n")
+	}
+	conn.Close()
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("PortForward failed: %s", err)
+	}
+}
@@ -0,0 +1,64 @@
+package instance
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Presets are named sets of parameter values per stack like
+//
+//	{"dhis2": {"daily": {"IMAGE_TAG": "2.39", "DATABASE_ID": "{{.Name}}-db"}}}
+//
+// Values are templates that can refer to the PresetData and to environment
+// variables using {{env "NAME"}}.
+type Presets map[string]map[string]map[string]string
+
+// PresetData is passed to the templates of preset values.
+type PresetData struct {
+	// Name of the instance that is created.
+	Name  string
+	Stack string
+	Group string
+	User  string
+}
+
+// Names returns the names of the presets of the stack sorted by name.
+func (p Presets) Names(stack string) []string {
+	var names []string
+	for name := range p[stack] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Params returns the parameters of the preset of the stack with their
+// templates executed using data.
+func (p Presets) Params(stack, preset string, data PresetData) (map[string]string, error) {
+	raw, ok := p[stack][preset]
+	if !ok {
+		if names := p.Names(stack); len(names) > 0 {
+			return nil, fmt.Errorf("stack %q has no preset %q, expected one of %s", stack, preset, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("stack %q has no presets", stack)
+	}
+
+	params := make(map[string]string, len(raw))
+	for name, value := range raw {
+		t, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+			"env": os.Getenv,
+		}).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s in preset %q: %w", name, preset, err)
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("invalid value of %s in preset %q: %w", name, preset, err)
+		}
+		params[name] = b.String()
+	}
+	return params, nil
+}
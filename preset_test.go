@@ -0,0 +1,40 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPresetParams(t *testing.T) {
+	t.Setenv("DB_DUMP", "sierra-leone")
+	presets := Presets{
+		"dhis2": {
+			"daily": {
+				"IMAGE_TAG":   "2.39",
+				"DATABASE_ID": "{{.Name}}-db",
+				"DUMP":        `{{env "DB_DUMP"}}`,
+			},
+			"broken": {"IMAGE_TAG": "{{.Unknown}}"},
+		},
+	}
+
+	got, err := presets.Params("dhis2", "daily", PresetData{Name: "dev", Stack: "dhis2"})
+	if err != nil {
+		t.Fatalf("Params failed: %s", err)
+	}
+	want := map[string]string{
+		"IMAGE_TAG":   "2.39",
+		"DATABASE_ID": "dev-db",
+		"DUMP":        "sierra-leone",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Params mismatch (-want +got): %s\n", diff)
+	}
+
+	for _, preset := range []string{"broken", "unknown"} {
+		if _, err := presets.Params("dhis2", preset, PresetData{}); err == nil {
+			t.Errorf("Params of preset %q succeeded, want error", preset)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package instance
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var promptStyle = lipgloss.NewStyle().Padding(0, 2)
+
+var promptKeys = struct {
+	Submit key.Binding
+	Cancel key.Binding
+}{
+	Submit: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "confirm"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+// promptHeight is the number of lines a prompt takes up.
+const promptHeight = 1
+
+// prompt asks the user for a single line of input. The command returned by
+// onSubmit is run once the user confirms the input.
+type prompt struct {
+	input    textinput.Model
+	active   bool
+	onSubmit func(value string) tea.Cmd
+}
+
+func newPrompt() prompt {
+	return prompt{input: textinput.New()}
+}
+
+// ask activates the prompt showing the label and the initial value.
+func (p *prompt) ask(label, value string, onSubmit func(value string) tea.Cmd) tea.Cmd {
+	p.active = true
+	p.onSubmit = onSubmit
+	p.input.Prompt = label + " "
+	p.input.SetValue(value)
+	p.input.CursorEnd()
+	return p.input.Focus()
+}
+
+func (p *prompt) close() {
+	p.active = false
+	p.onSubmit = nil
+	p.input.Blur()
+}
+
+func (p prompt) update(msg tea.Msg) (prompt, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, promptKeys.Submit):
+			submit, value := p.onSubmit, p.input.Value()
+			p.close()
+			if submit == nil {
+				return p, nil
+			}
+			return p, submit(value)
+		case key.Matches(msg, promptKeys.Cancel):
+			p.close()
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p prompt) view() string {
+	hint := errorHint.Render("  enter confirm • esc cancel")
+	return promptStyle.Render(p.input.View() + hint)
+}
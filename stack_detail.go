@@ -0,0 +1,70 @@
+package instance
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	detailTitle   = lipgloss.NewStyle().Bold(true).Foreground(highlight).MarginBottom(1)
+	detailSection = lipgloss.NewStyle().Bold(true).Underline(true).MarginTop(1).MarginBottom(1)
+)
+
+// renderStack renders the stack with its parameters and the instances
+// currently using it.
+func renderStack(st *Stack) string {
+	var b strings.Builder
+	b.WriteString(detailTitle.Render(st.Name+" ("+strconv.Itoa(st.ID)+")") + "\n")
+
+	rows := [][]string{
+		{"Created", FormatTime(st.CreatedAt)},
+		{"Updated", FormatTime(st.UpdatedAt)},
+		{"Instances", strconv.Itoa(len(st.Instances))},
+	}
+	b.WriteString(renderTable([]string{"ATTRIBUTE", "VALUE"}, rows) + "\n")
+
+	b.WriteString(detailSection.Render("Required parameters") + "\n")
+	rows = nil
+	for _, p := range st.RequiredParams {
+		rows = append(rows, []string{p.Name})
+	}
+	b.WriteString(renderTable([]string{"NAME"}, rows) + "\n")
+
+	b.WriteString(detailSection.Render("Optional parameters") + "\n")
+	rows = nil
+	for _, p := range st.OptionalParams {
+		rows = append(rows, []string{p.Name, p.DefaultValue})
+	}
+	b.WriteString(renderTable([]string{"NAME", "DEFAULT"}, rows) + "\n")
+
+	b.WriteString(detailSection.Render("Instances") + "\n")
+	rows = nil
+	for _, in := range st.Instances {
+		rows = append(rows, []string{in.Name, strconv.Itoa(in.ID), strconv.Itoa(in.GroupID)})
+	}
+	b.WriteString(renderTable([]string{"NAME", "ID", "GROUP"}, rows))
+
+	return b.String()
+}
+
+// renderStackDiff renders the parameters of stack a and b side by side
+// highlighting the ones that differ.
+func renderStackDiff(a, b *Stack) string {
+	var sb strings.Builder
+	sb.WriteString(detailTitle.Render(a.Name+" ↔ "+b.Name) + "\n")
+
+	var rows [][]string
+	for _, d := range DiffStacks(a, b) {
+		style := d.Kind.Style()
+		rows = append(rows, []string{
+			style.Render(d.Kind.Mark() + " " + d.Name),
+			style.Render(FormatParamDef(d.A)),
+			style.Render(FormatParamDef(d.B)),
+		})
+	}
+	sb.WriteString(renderTable([]string{"PARAMETER", strings.ToUpper(a.Name), strings.ToUpper(b.Name)}, rows))
+
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package instance
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles of added, removed and changed values in diffs.
+var (
+	AddedText   = lipgloss.NewStyle().Foreground(lipgloss.Color("#43BF6D"))
+	RemovedText = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	ChangedText = lipgloss.NewStyle().Foreground(lipgloss.Color("#F2C94C"))
+)
+
+// DiffKind describes how a parameter differs between two stacks.
+type DiffKind int
+
+const (
+	ParamSame DiffKind = iota
+	// ParamAdded is a parameter only the second stack has.
+	ParamAdded
+	// ParamRemoved is a parameter only the first stack has.
+	ParamRemoved
+	// ParamChanged is a parameter that is required in one stack and optional
+	// in the other or has a different default value.
+	ParamChanged
+)
+
+// Mark returns the mark prefixing parameters of the kind in diffs.
+func (k DiffKind) Mark() string {
+	switch k {
+	case ParamAdded:
+		return "+"
+	case ParamRemoved:
+		return "-"
+	case ParamChanged:
+		return "~"
+	}
+	return " "
+}
+
+// Style returns the style parameters of the kind are rendered in.
+func (k DiffKind) Style() lipgloss.Style {
+	switch k {
+	case ParamAdded:
+		return AddedText
+	case ParamRemoved:
+		return RemovedText
+	case ParamChanged:
+		return ChangedText
+	}
+	return lipgloss.NewStyle()
+}
+
+// ParamDef is the definition of a parameter in a stack.
+type ParamDef struct {
+	Required bool
+	Default  string
+}
+
+func (p ParamDef) String() string {
+	if p.Required {
+		return "required"
+	}
+	if p.Default == "" {
+		return "optional"
+	}
+	return "optional: " + p.Default
+}
+
+// FormatParamDef returns the definition of the parameter or "-" if the stack
+// does not have it.
+func FormatParamDef(p *ParamDef) string {
+	if p == nil {
+		return "-"
+	}
+	return p.String()
+}
+
+// ParamDiff compares the definition of a parameter in stack A to the one in
+// stack B. A is nil if the parameter was added and B is nil if it was
+// removed.
+type ParamDiff struct {
+	Name string
+	Kind DiffKind
+	A, B *ParamDef
+}
+
+// DiffStacks compares the required and optional parameters of stack a to the
+// ones of stack b. The parameters of both stacks are returned sorted by name.
+func DiffStacks(a, b *Stack) []ParamDiff {
+	as, bs := paramDefs(a), paramDefs(b)
+	names := make(map[string]bool)
+	for name := range as {
+		names[name] = true
+	}
+	for name := range bs {
+		names[name] = true
+	}
+
+	var diffs []ParamDiff
+	for name := range names {
+		d := ParamDiff{Name: name, A: as[name], B: bs[name]}
+		switch {
+		case d.A == nil:
+			d.Kind = ParamAdded
+		case d.B == nil:
+			d.Kind = ParamRemoved
+		case *d.A != *d.B:
+			d.Kind = ParamChanged
+		}
+		diffs = append(diffs, d)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+func paramDefs(st *Stack) map[string]*ParamDef {
+	defs := make(map[string]*ParamDef)
+	for _, p := range st.RequiredParams {
+		defs[p.Name] = &ParamDef{Required: true}
+	}
+	for _, p := range st.OptionalParams {
+		defs[p.Name] = &ParamDef{Default: p.DefaultValue}
+	}
+	return defs
+}
@@ -0,0 +1,31 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffStacks(t *testing.T) {
+	a := &Stack{
+		RequiredParams: []RequiredParam{{Name: "DATABASE_ID"}, {Name: "IMAGE_TAG"}},
+		OptionalParams: []OptionalParam{{Name: "CHART_VERSION", DefaultValue: "1.0"}, {Name: "INSTANCE_TTL"}},
+	}
+	b := &Stack{
+		RequiredParams: []RequiredParam{{Name: "IMAGE_TAG"}},
+		OptionalParams: []OptionalParam{{Name: "CHART_VERSION", DefaultValue: "2.0"}, {Name: "DATABASE_HOSTNAME"}, {Name: "INSTANCE_TTL"}},
+	}
+
+	got := DiffStacks(a, b)
+
+	want := []ParamDiff{
+		{Name: "CHART_VERSION", Kind: ParamChanged, A: &ParamDef{Default: "1.0"}, B: &ParamDef{Default: "2.0"}},
+		{Name: "DATABASE_HOSTNAME", Kind: ParamAdded, B: &ParamDef{}},
+		{Name: "DATABASE_ID", Kind: ParamRemoved, A: &ParamDef{Required: true}},
+		{Name: "IMAGE_TAG", Kind: ParamSame, A: &ParamDef{Required: true}, B: &ParamDef{Required: true}},
+		{Name: "INSTANCE_TTL", Kind: ParamSame, A: &ParamDef{}, B: &ParamDef{}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DiffStacks() mismatch (-want +got): %s\n", diff)
+	}
+}
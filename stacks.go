@@ -2,80 +2,69 @@ package instance
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
-type item struct {
-	title, desc string
+type stacks struct {
+	panes
+	prompt  prompt
+	manager *Manager
+	presets Presets
+	// group instances are created in.
+	group         string
+	raw           bool
+	sort          sortMode
+	stacks        []Stack
+	stacksDetails map[int]*Stack
+	stacksJson    map[int]string
+	// compareID is the ID of the stack the selected stack is compared to.
+	// Stacks are not compared if it is 0.
+	compareID int
 }
 
-func (i item) Title() string       { return i.title }
-func (i item) Description() string { return i.desc }
-func (i item) FilterValue() string { return i.title }
+var stacksKeys = struct {
+	ToggleRaw key.Binding
+	Compare   key.Binding
+	Create    key.Binding
+}{
+	ToggleRaw: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "toggle raw JSON"),
+	),
+	Compare: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "compare"),
+	),
+	Create: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new instance"),
+	),
+}
 
-type stacks struct {
-	manager       *Manager
-	ready         bool
-	list          list.Model
-	viewport      viewport.Model
-	curIndex      int
-	curStackJson  string
-	stacks        []Stacks
-	stacksDetails []*Stack
-	stacksJson    []string
-}
-
-type selectItemMsg struct {
-	index int
-}
-
-func NewStacks(im *Manager) stacks {
-	d := list.NewDefaultDelegate()
-	d.ShowDescription = false
-	d.UpdateFunc = func(msg tea.Msg, m *list.Model) tea.Cmd {
-		// get the currently selected item
-		// cannot just listen to mouse down/up events as list model has not
-		// been updated as stacks model receives the event before. Thus, rely
-		// on a delegate which is called after the list model was updated.
-		return func() tea.Msg {
-			return selectItemMsg{index: m.Index()}
-		}
+// NewStacks creates the stacks component. The presets can be chosen when
+// creating instances of a stack.
+func NewStacks(im *Manager, layout Layout, presets Presets) stacks {
+	return stacks{
+		panes:   newPanes(layout),
+		prompt:  newPrompt(),
+		manager: im,
+		presets: presets,
 	}
+}
 
-	var items []list.Item
-	list := list.New(items, d, 0, 0)
-	list.SetShowTitle(false)
-	list.SetShowHelp(false)
-
-	view := viewport.New(0, 0)
-	view.KeyMap = viewport.KeyMap{
-		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", " ", "f"),
-		),
-		PageUp: key.NewBinding(
-			key.WithKeys("pgup", "b"),
-		),
-		Up: key.NewBinding(
-			key.WithKeys("u", "ctrl+u"),
-		),
-		Down: key.NewBinding(
-			key.WithKeys("d", "ctrl+d"),
-		),
-	}
+func (m stacks) Title() string {
+	return "Stacks"
+}
 
-	return stacks{
-		manager:  im,
-		list:     list,
-		viewport: view,
-		curIndex: -1,
-	}
+func (m stacks) CapturingInput() bool {
+	return m.prompt.active || m.filtering()
 }
 
 func (m stacks) Init() tea.Cmd {
@@ -83,7 +72,7 @@ func (m stacks) Init() tea.Cmd {
 }
 
 type stacksMsg struct {
-	stacks []Stacks
+	stacks []Stack
 	items  []list.Item
 }
 
@@ -91,19 +80,25 @@ func (m stacks) fetchStacks() tea.Cmd {
 	return func() tea.Msg {
 		sts, err := m.manager.Stacks()
 		if err != nil {
-			return err
+			return errMsg{err: err, retry: m.fetchStacks()}
 		}
 		var items []list.Item
 		for _, st := range sts {
-			items = append(items, item{title: fmt.Sprintf("%s (%d)", st.Name, st.ID)})
+			items = append(items, item{
+				id:        st.ID,
+				title:     fmt.Sprintf("%s (%d)", st.Name, st.ID),
+				filter:    filterValue(st.Name, strconv.Itoa(st.ID)),
+				createdAt: st.CreatedAt,
+				updatedAt: st.UpdatedAt,
+			})
 		}
 		return stacksMsg{stacks: sts, items: items}
 	}
 }
 
 type stacksDetailsMsg struct {
-	stacks     []*Stack
-	stacksJson []string
+	stacks     map[int]*Stack
+	stacksJson map[int]string
 }
 
 func (m stacks) fetchStacksDetails() tea.Cmd {
@@ -113,98 +108,200 @@ func (m stacks) fetchStacksDetails() tea.Cmd {
 			ids = append(ids, st.ID)
 		}
 		sts, err := m.manager.StackDetails(ids...)
-		// TODO put into message and handle in view
 		if err != nil {
-			return err
+			return errMsg{err: err, retry: m.fetchStacksDetails()}
+		}
+		ins, err := m.manager.StackInstances()
+		if err != nil {
+			return errMsg{err: err, retry: m.fetchStacksDetails()}
 		}
 
-		var stackJson []string
+		details := make(map[int]*Stack)
+		stackJson := make(map[int]string)
 		for _, st := range sts {
 			sj, err := json.MarshalIndent(st, "", "  ")
 			if err != nil {
-				return err
+				return errMsg{err: err}
 			}
-			stackJson = append(stackJson, string(sj))
+			// the instance manager does not send the instances of stacks
+			st.Instances = ins[st.ID]
+			details[st.ID] = st
+			stackJson[st.ID] = colorizeJSON(string(sj))
 		}
 		return stacksDetailsMsg{
-			stacks:     sts,
+			stacks:     details,
 			stacksJson: stackJson,
 		}
 	}
 }
 
+// presetChosenMsg is sent once the user chose the preset of the instance to
+// create.
+type presetChosenMsg struct {
+	stack  Stack
+	preset string
+}
+
 func (m stacks) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
+	if _, ok := msg.(tea.KeyMsg); ok && m.prompt.active {
+		var cmd tea.Cmd
+		m.prompt, cmd = m.prompt.update(msg)
+		if !m.prompt.active {
+			m.reserve(0)
+		}
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
+	case groupMsg:
+		m.group = msg.group
+		return m, nil
+	case presetChosenMsg:
+		m.reserve(promptHeight)
+		cmd := m.prompt.ask("Name of the new "+msg.stack.Name+" instance:", "", func(name string) tea.Cmd {
+			return m.createInstance(msg.stack, msg.preset, strings.TrimSpace(name))
+		})
+		return m, cmd
 	case stacksMsg:
 		m.stacks = msg.stacks
-		cmds = append(cmds, m.list.SetItems(msg.items))
-		cmds = append(cmds, m.fetchStacksDetails())
-		// TODO return or let it fall through?
-		return m, tea.Batch(cmds...)
+		cmd, _ := m.setItems(msg.items, m.sort)
+		m.showStack()
+		return m, tea.Batch(
+			cmd,
+			m.fetchStacksDetails(),
+			setStatus("fetched %d stacks", len(msg.stacks)),
+		)
 	case stacksDetailsMsg:
 		m.stacksDetails = msg.stacks
 		m.stacksJson = msg.stacksJson
-		// TODO should I set the content here as well as in selectItemMsg?
-		if m.curIndex >= 0 {
-			m.curStackJson = m.stacksJson[m.curIndex]
-			m.viewport.SetContent(m.curStackJson)
+		m.showStack()
+		return m, setStatus("fetched details of %d stacks", len(msg.stacks))
+	case tea.KeyMsg:
+		if m.filtering() {
+			break
 		}
-		return m, nil
-	case selectItemMsg:
-		if msg.index != m.curIndex {
-			m.curIndex = msg.index
-			if len(m.stacksJson) > 0 {
-				m.curStackJson = m.stacksJson[m.curIndex]
-				m.viewport.SetContent(m.curStackJson)
-			}
+		switch {
+		case key.Matches(msg, stacksKeys.ToggleRaw):
+			m.raw = !m.raw
+			m.showStack()
 			return m, nil
-		}
-	case tea.WindowSizeMsg:
-		// TODO this should not take up all the space
-		h, v := docStyle.GetFrameSize()
-		// TODO split space more "equally" between the list and the viewport
-		m.list.SetSize(msg.Width-h, msg.Height-v)
-
-		if !m.ready {
-			// Since this program is using the full size of the viewport we
-			// need to wait until we've received the window dimensions before
-			// we can initialize the viewport. The initial dimensions come in
-			// quickly, though asynchronously, which is why we wait for them
-			// here.
-			m.viewport.Width = msg.Width - h
-			m.viewport.Height = msg.Height - v
-			m.viewport.SetContent(m.curStackJson)
-			m.ready = true
-		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - v
+		case key.Matches(msg, stacksKeys.Create):
+			st, ok := m.stack(m.curID)
+			if !ok {
+				return m, nil
+			}
+			if m.group == "" {
+				return m, setError(errors.New("select the group to create the instance in using " + keys.Group.Help().Key))
+			}
+			names := m.presets.Names(st.Name)
+			if len(names) == 0 {
+				return m, func() tea.Msg { return presetChosenMsg{stack: st} }
+			}
+			m.reserve(promptHeight)
+			label := fmt.Sprintf("Preset (%s, empty for none):", strings.Join(names, ", "))
+			cmd := m.prompt.ask(label, names[0], func(preset string) tea.Cmd {
+				return func() tea.Msg {
+					return presetChosenMsg{stack: st, preset: strings.TrimSpace(preset)}
+				}
+			})
+			return m, cmd
+		case key.Matches(msg, stacksKeys.Compare):
+			if m.compareID != 0 {
+				m.compareID = 0
+				m.showStack()
+				return m, setStatus("stopped comparing stacks")
+			}
+			st, ok := m.stacksDetails[m.curID]
+			if !ok {
+				return m, nil
+			}
+			m.compareID = st.ID
+			return m, setStatus("comparing with %s, select another stack", st.Name)
+		case key.Matches(msg, listKeys.Sort):
+			m.sort = m.sort.next()
+			cmd, _ := m.setItems(m.list.Items(), m.sort)
+			m.showStack()
+			return m, tea.Batch(cmd, setStatus("sorted stacks by %s", m.sort))
 		}
 	}
 
-	// Handle keyboard and mouse events
-	var cmd tea.Cmd
-	m.list, cmd = m.list.Update(msg)
-	cmds = append(cmds, cmd)
-	m.viewport, cmd = m.viewport.Update(msg)
+	var cmds []tea.Cmd
+	if m.prompt.active {
+		// let the prompt blink its cursor
+		var cmd tea.Cmd
+		m.prompt, cmd = m.prompt.update(msg)
+		cmds = append(cmds, cmd)
+	}
+	cmd, changed := m.update(msg)
 	cmds = append(cmds, cmd)
-
+	if changed {
+		m.showStack()
+	}
 	return m, tea.Batch(cmds...)
 }
 
-func (m stacks) View() string {
-	var doc strings.Builder
-	list := docStyle.Render(m.list.View())
-
-	if m.curStackJson != "" {
-		doc.WriteString(lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			list,
-			docStyle.Render(m.viewport.View()),
-		))
-	} else {
-		doc.WriteString(list)
+func (m stacks) stack(id int) (Stack, bool) {
+	for _, st := range m.stacks {
+		if st.ID == id {
+			return st, true
+		}
+	}
+	return Stack{}, false
+}
+
+// createInstance creates an instance of the stack in the group using the
+// parameters of the preset. No parameters are passed if the preset is empty.
+func (m stacks) createInstance(st Stack, preset, name string) tea.Cmd {
+	group := m.group
+	return func() tea.Msg {
+		if name == "" {
+			return statusMsg{text: "creating instance aborted"}
+		}
+		params := map[string]string{}
+		if preset != "" {
+			var err error
+			params, err = m.presets.Params(st.Name, preset, PresetData{
+				Name:  name,
+				Stack: st.Name,
+				Group: group,
+				User:  m.manager.User(),
+			})
+			if err != nil {
+				return errMsg{err: err}
+			}
+		}
+		groupID, err := m.manager.GroupID(group)
+		if err != nil {
+			return errMsg{err: err, retry: m.createInstance(st, preset, name)}
+		}
+		in, err := m.manager.Create(name, groupID, st.ID, params)
+		if err != nil {
+			return errMsg{err: err, retry: m.createInstance(st, preset, name)}
+		}
+		return instancesChangedMsg{status: fmt.Sprintf("created %s with id %d in group %s", in.Name, in.ID, group)}
 	}
+}
+
+// showStack shows the currently selected stack either rendered or as raw
+// JSON. The stack is compared to the one marked for comparison if any.
+func (m *stacks) showStack() {
+	st, ok := m.stacksDetails[m.curID]
+	cmp, comparing := m.stacksDetails[m.compareID]
+	switch {
+	case !ok:
+		m.setDetail("")
+	case comparing && cmp.ID != st.ID:
+		m.setDetail(renderStackDiff(cmp, st))
+	case m.raw:
+		m.setDetail(m.stacksJson[m.curID])
+	default:
+		m.setDetail(renderStack(st))
+	}
+}
 
-	return doc.String()
+func (m stacks) View() string {
+	if m.prompt.active {
+		return m.view() + "\n" + m.prompt.view()
+	}
+	return m.view()
 }
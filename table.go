@@ -0,0 +1,53 @@
+package instance
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tableHeader = lipgloss.NewStyle().Bold(true).Foreground(highlight)
+	tableCell   = lipgloss.NewStyle().PaddingRight(2)
+	tableEmpty  = lipgloss.NewStyle().Faint(true)
+)
+
+// renderTable renders rows in columns aligned to the widest cell of each
+// column.
+func renderTable(headers []string, rows [][]string) string {
+	if len(rows) == 0 {
+		return tableEmpty.Render("none")
+	}
+
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = lipgloss.Width(h)
+	}
+	for _, r := range rows {
+		for i, c := range r {
+			if i < len(widths) {
+				widths[i] = max(widths[i], lipgloss.Width(c))
+			}
+		}
+	}
+
+	var b strings.Builder
+	var cells []string
+	for i, h := range headers {
+		cells = append(cells, tableCell.Copy().Width(widths[i]+2).Render(tableHeader.Render(h)))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	for _, r := range rows {
+		cells = cells[:0]
+		for i := range headers {
+			var c string
+			if i < len(r) {
+				c = r[i]
+			}
+			cells = append(cells, tableCell.Copy().Width(widths[i]+2).Render(c))
+		}
+		b.WriteString("\n" + lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	return b.String()
+}
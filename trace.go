@@ -0,0 +1,178 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTraceBody is the maximum number of bytes of a body that are logged.
+const maxTraceBody = 16 << 10
+
+const redacted = "<redacted>"
+
+// sensitiveKeys are the keys of JSON bodies whose values are redacted.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"refreshtoken":  true,
+	"secret":        true,
+}
+
+// Tracer is a http.RoundTripper logging requests sent by the manager and the
+// responses to them. Credentials in headers and bodies are redacted.
+type Tracer struct {
+	transport http.RoundTripper
+	log       *log.Logger
+	// bodies also logs headers and bodies of requests and responses.
+	bodies bool
+}
+
+// NewTracer returns a tracer logging to w the method, URL, status and
+// latency of requests sent via transport. Headers and bodies are logged if
+// bodies is true. The http.DefaultTransport is used if transport is nil.
+func NewTracer(transport http.RoundTripper, w io.Writer, bodies bool) *Tracer {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Tracer{
+		transport: transport,
+		log:       log.New(w, "", log.LstdFlags|log.Lmicroseconds),
+		bodies:    bodies,
+	}
+}
+
+func (t *Tracer) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.log.Printf("--> %s %s", req.Method, req.URL.Redacted())
+	if t.bodies {
+		t.logHeader(req.Header)
+		if req.Body != nil && req.Body != http.NoBody {
+			b, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(b))
+			t.logBody(b)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.log.Printf("<-- %s %s failed after %s: %s", req.Method, req.URL.Redacted(), latency, err)
+		return nil, err
+	}
+	t.log.Printf("<-- %s %s %s (%s)", resp.Status, req.Method, req.URL.Redacted(), latency)
+	// the body of a switched protocol is a connection and not read
+	if t.bodies && resp.StatusCode != http.StatusSwitchingProtocols {
+		t.logHeader(resp.Header)
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(b))
+		t.logBody(b)
+	}
+	return resp, nil
+}
+
+func (t *Tracer) logHeader(h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			t.log.Printf("    %s: %s", name, redactHeader(name, v))
+		}
+	}
+}
+
+func (t *Tracer) logBody(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	b = redactBody(b)
+	if len(b) > maxTraceBody {
+		b = append(b[:maxTraceBody:maxTraceBody], fmt.Sprintf("... (%d bytes)", len(b))...)
+	}
+	t.log.Printf("    %s", b)
+}
+
+// redactHeader redacts the credentials in the value of the header with given
+// name keeping the authentication scheme.
+func redactHeader(name, value string) string {
+	switch http.CanonicalHeaderKey(name) {
+	case "Authorization", "Proxy-Authorization":
+		if scheme, _, ok := strings.Cut(value, " "); ok {
+			return scheme + " " + redacted
+		}
+		return redacted
+	case "Cookie", "Set-Cookie":
+		return redacted
+	}
+	return value
+}
+
+// redactBody redacts the values of sensitive keys in JSON bodies. Other
+// bodies are returned as is.
+func redactBody(b []byte) []byte {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return b
+	}
+	var r bytes.Buffer
+	e := json.NewEncoder(&r)
+	e.SetEscapeHTML(false)
+	if err := e.Encode(redactJSON(v)); err != nil {
+		return b
+	}
+	return bytes.TrimSuffix(r.Bytes(), []byte("\n"))
+}
+
+// redactJSON redacts the values of sensitive keys and of parameters with a
+// sensitive name like {"name": "DATABASE_PASSWORD", "value": "secret"}.
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		sensitiveParam := false
+		for k, val := range v {
+			if name, ok := val.(string); ok && strings.EqualFold(k, "name") && isSensitiveParam(name) {
+				sensitiveParam = true
+			}
+		}
+		for k, val := range v {
+			if sensitiveParam && (strings.EqualFold(k, "value") || strings.EqualFold(k, "defaultValue")) {
+				v[k] = redacted
+				continue
+			}
+			if sensitiveKeys[strings.ToLower(k)] {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactJSON(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val)
+		}
+	}
+	return v
+}
+
+func isSensitiveParam(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.Contains(name, "PASSWORD") || strings.Contains(name, "SECRET") || strings.Contains(name, "TOKEN")
+}
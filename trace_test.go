@@ -0,0 +1,45 @@
+package instance
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTracerRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"access_token":"eyJhbGci","expires_in":900}`)
+	}))
+	defer srv.Close()
+	var out bytes.Buffer
+	client := srv.Client()
+	client.Transport = NewTracer(client.Transport, &out, true)
+
+	body := `{"name":"dev","requiredParameters":[{"Name":"DATABASE_PASSWORD","Value":"dhis"},{"Name":"IMAGE_TAG","Value":"2.39"}]}`
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/instances", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("user", "secret-pw")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	got := out.String()
+	for _, want := range []string{"--> POST " + srv.URL + "/instances", "<-- 201 Created POST", "Authorization: Basic <redacted>", `"Value":"2.39"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("trace does not contain %q:\n%s", want, got)
+		}
+	}
+	for _, secret := range []string{"dXNlcjpzZWNyZXQtcHc=", `"dhis"`, "eyJhbGci"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("trace contains secret %q:\n%s", secret, got)
+		}
+	}
+}
@@ -1,20 +1,20 @@
 package instance
 
 import (
-	"os"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"golang.org/x/term"
 )
 
 const (
-	// In real life situations we'd adjust the document to fit the width we've
-	// detected. In the case of this example we're hardcoding the width, and
-	// later using the detected width only to truncate in order to avoid jaggy
-	// wrapping.
-	width = 96
+	// defaultWidth is used until the terminal dimensions are known.
+	defaultWidth = 96
 )
 
 // Style definitions.
@@ -80,79 +80,470 @@ var (
 
 	statusText = lipgloss.NewStyle().Inherit(statusBarStyle)
 
+	statusErrorText = statusText.Copy().Foreground(lipgloss.Color("#FF5F87"))
+
+	expiryStyle = statusNugget.Copy().Background(lipgloss.Color("#A550DF"))
+
 	managerUrlStyle = statusNugget.Copy().Background(lipgloss.Color("#6124DF"))
+
+	groupStyle = statusNugget.Copy().Background(lipgloss.Color("#43BF6D"))
+
+	// Error banner.
+
+	errorBanner = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#FF5F87")).
+			Padding(0, 1)
+
+	errorTitle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FF5F87"))
+
+	errorHint = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#9B9B9B", Dark: "#5C5C5C"})
 )
 
+type keyMap struct {
+	NextTab key.Binding
+	PrevTab key.Binding
+	Group   key.Binding
+	Retry   key.Binding
+	Dismiss key.Binding
+	Quit    key.Binding
+}
+
+var keys = keyMap{
+	NextTab: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next tab"),
+	),
+	PrevTab: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous tab"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "switch group"),
+	),
+	Retry: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "retry"),
+	),
+	Dismiss: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "dismiss"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// errMsg is emitted by components if a command failed. The error is shown in
+// a banner until it is dismissed or the command is retried using retry. A nil
+// retry means the command cannot be retried.
+type errMsg struct {
+	err   error
+	retry tea.Cmd
+}
+
+func (e errMsg) Error() string {
+	return e.err.Error()
+}
+
+// statusTimeout is the duration a status message is shown in the status bar.
+const statusTimeout = 5 * time.Second
+
+// statusMsg is a transient message shown in the status bar. Components can
+// emit it using setStatus or setError.
+type statusMsg struct {
+	text  string
+	isErr bool
+}
+
+func setStatus(format string, a ...any) tea.Cmd {
+	return func() tea.Msg {
+		return statusMsg{text: fmt.Sprintf(format, a...)}
+	}
+}
+
+func setError(err error) tea.Cmd {
+	return func() tea.Msg {
+		return statusMsg{text: err.Error(), isErr: true}
+	}
+}
+
+// clearStatusMsg clears the status message with given id. The id prevents
+// clearing a message that replaced the one the timer was started for.
+type clearStatusMsg struct {
+	id int
+}
+
+type meMsg struct {
+	user *User
+}
+
+// groupMsg is sent to all components if the user switched the group.
+// Components showing resources of groups only show the ones of the group. An
+// empty group means all groups.
+type groupMsg struct {
+	group string
+}
+
+type tickMsg time.Time
+
+// titled is implemented by components to provide the title of their tab.
+type titled interface {
+	Title() string
+}
+
+// capturer is implemented by components reading text input like prompts,
+// forms or list filters. While a component captures input all keys but quit
+// are passed on to it so that typing does not trigger global keys.
+type capturer interface {
+	CapturingInput() bool
+}
+
 type UI struct {
-	manager   *Manager
-	component tea.Model
+	manager    *Manager
+	components []tea.Model
+	active     int
+	user       *User
+	group      string
+	err        *errMsg
+	status     statusMsg
+	statusID   int
+	now        time.Time
+	width      int
+	height     int
 }
 
-func NewUI(im *Manager, component tea.Model) tea.Model {
+// NewUI creates the UI showing each component in its own tab.
+func NewUI(im *Manager, components ...tea.Model) tea.Model {
 	return &UI{
-		manager:   im,
-		component: component,
+		manager:    im,
+		components: components,
+		now:        time.Now(),
+		width:      defaultWidth,
 	}
 }
 
 func (ui UI) Init() tea.Cmd {
-	return ui.component.Init()
+	cmds := []tea.Cmd{ui.fetchMe(), tick()}
+	for _, c := range ui.components {
+		cmds = append(cmds, c.Init())
+	}
+	return tea.Batch(cmds...)
+}
+
+func (ui UI) fetchMe() tea.Cmd {
+	return func() tea.Msg {
+		u, err := ui.manager.Me()
+		if err != nil {
+			return errMsg{err: err, retry: ui.fetchMe()}
+		}
+		return meMsg{user: u}
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
 }
 
 func (ui UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	// TODO use WindowSizeMsg to set width and all
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		ui.width, ui.height = msg.Width, msg.Height
+		return ui.resize()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return ui, tea.Quit
+		case ui.capturingInput():
+			return ui.updateActive(msg)
+		case key.Matches(msg, keys.NextTab):
+			ui.active = (ui.active + 1) % len(ui.components)
+			return ui, nil
+		case key.Matches(msg, keys.PrevTab):
+			ui.active = (ui.active - 1 + len(ui.components)) % len(ui.components)
+			return ui, nil
+		case key.Matches(msg, keys.Group):
+			ui.group = ui.nextGroup()
+			ui, cmd := ui.updateAll(groupMsg{group: ui.group})
+			if ui.group == "" {
+				return ui, tea.Batch(cmd, setStatus("showing all groups"))
+			}
+			return ui, tea.Batch(cmd, setStatus("showing group %s", ui.group))
+		case ui.err != nil && key.Matches(msg, keys.Retry):
+			retry := ui.err.retry
+			ui.err = nil
+			ui, cmd := ui.resize()
+			if retry == nil {
+				return ui, cmd
+			}
+			return ui, tea.Batch(cmd, retry, setStatus("retrying"))
+		case ui.err != nil && key.Matches(msg, keys.Dismiss):
+			ui.err = nil
+			return ui.resize()
+		}
+		return ui.updateActive(msg)
+	case list.FilterMatchesMsg:
+		// lists of all components emit this message
+		return ui.updateActive(msg)
+	case errMsg:
+		ui.err = &msg
+		return ui.resize()
+	case meMsg:
+		ui.user = msg.user
+		return ui, nil
+	case tickMsg:
+		ui.now = time.Time(msg)
+		ui, cmd := ui.updateAll(msg)
+		return ui, tea.Batch(cmd, tick())
+	case statusMsg:
+		ui.statusID++
+		ui.status = msg
+		id := ui.statusID
+		return ui, tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+			return clearStatusMsg{id: id}
+		})
+	case clearStatusMsg:
+		if msg.id == ui.statusID {
+			ui.status = statusMsg{}
+		}
+		return ui, nil
+	}
 
+	return ui.updateAll(msg)
+}
+
+// nextGroup returns the group following the current one in the groups of the
+// user. All groups follow the last one.
+func (ui UI) nextGroup() string {
+	if ui.user == nil || len(ui.user.Groups) == 0 {
+		return ""
+	}
+	if ui.group == "" {
+		return ui.user.Groups[0].Name
+	}
+	for i, g := range ui.user.Groups {
+		if g.Name == ui.group && i+1 < len(ui.user.Groups) {
+			return ui.user.Groups[i+1].Name
+		}
+	}
+	return ""
+}
+
+// capturingInput reports whether the component of the active tab captures
+// text input.
+func (ui UI) capturingInput() bool {
+	if len(ui.components) == 0 {
+		return false
+	}
+	c, ok := ui.components[ui.active].(capturer)
+	return ok && c.CapturingInput()
+}
+
+// updateActive passes the message on to the component of the active tab.
+func (ui UI) updateActive(msg tea.Msg) (UI, tea.Cmd) {
+	if len(ui.components) == 0 {
+		return ui, nil
+	}
 	var cmd tea.Cmd
-	ui.component, cmd = ui.component.Update(msg)
+	ui.components[ui.active], cmd = ui.components[ui.active].Update(msg)
 	return ui, cmd
 }
 
+// updateAll passes the message on to all components as they might be waiting
+// for a response while their tab is not active.
+func (ui UI) updateAll(msg tea.Msg) (UI, tea.Cmd) {
+	var cmds []tea.Cmd
+	for i, c := range ui.components {
+		var cmd tea.Cmd
+		ui.components[i], cmd = c.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return ui, tea.Batch(cmds...)
+}
+
+// resize passes the space left after the tabs, the error banner and the
+// status bar on to the component.
+func (ui UI) resize() (UI, tea.Cmd) {
+	if ui.height == 0 {
+		return ui, nil
+	}
+	return ui.updateAll(tea.WindowSizeMsg{
+		Width:  ui.contentWidth(),
+		Height: ui.componentHeight(),
+	})
+}
+
+// componentHeight is the height available to the component.
+func (ui UI) componentHeight() int {
+	_, v := docStyle.GetFrameSize()
+	header := ui.tabsView()
+	if ui.err != nil {
+		header += ui.errorView()
+	}
+	return max(0, ui.height-v-lipgloss.Height(header)-lipgloss.Height(ui.statusView()))
+}
+
+// contentWidth is the width available within the document.
+func (ui UI) contentWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	return max(0, ui.width-h)
+}
+
 func (ui UI) View() string {
-	physicalWidth, _, _ := term.GetSize(int(os.Stdout.Fd()))
 	doc := strings.Builder{}
 
-	// Tabs
-	{
-		row := lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			activeTab.Render("Stacks"),
-			tab.Render("Instances"),
-		)
-		gap := tabGap.Render(strings.Repeat(" ", max(0, width-lipgloss.Width(row)-2)))
-		row = lipgloss.JoinHorizontal(lipgloss.Bottom, row, gap)
-		doc.WriteString(row + "\n\n")
+	doc.WriteString(ui.tabsView())
+
+	if ui.err != nil {
+		doc.WriteString(ui.errorView())
 	}
 
 	// Current Component
 	{
-		doc.WriteString(ui.component.View())
+		var view string
+		if len(ui.components) > 0 {
+			view = ui.components[ui.active].View()
+		}
+		if ui.height > 0 {
+			// pin the status bar to the bottom
+			height := ui.componentHeight()
+			view = lipgloss.NewStyle().Height(height).MaxHeight(height).Render(view)
+		}
+		doc.WriteString(view + "\n")
 	}
 
-	// Status bar
-	{
-		w := lipgloss.Width
+	doc.WriteString(ui.statusView())
 
-		// TODO fill in real data
-		auth := statusStyle.Render("user@some.com")
-		managerUrl := managerUrlStyle.Render("@ instance.test.com")
-		statusVal := statusText.Copy().
-			Width(width - w(auth) - w(managerUrl)).
-			Render("Ravishing")
+	return docStyle.MaxWidth(ui.width).Render(doc.String())
+}
 
-		bar := lipgloss.JoinHorizontal(lipgloss.Top,
-			auth,
-			statusVal,
-			managerUrl,
-		)
+func (ui UI) tabsView() string {
+	width := ui.contentWidth()
+	var tabs []string
+	for i, c := range ui.components {
+		title := fmt.Sprintf("Tab %d", i+1)
+		if t, ok := c.(titled); ok {
+			title = t.Title()
+		}
+		if i == ui.active {
+			tabs = append(tabs, activeTab.Render(title))
+		} else {
+			tabs = append(tabs, tab.Render(title))
+		}
+	}
+	row := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	gap := tabGap.Render(strings.Repeat(" ", max(0, width-lipgloss.Width(row)-2)))
+	row = lipgloss.JoinHorizontal(lipgloss.Bottom, row, gap)
+	return row + "\n"
+}
+
+func (ui UI) statusView() string {
+	width := ui.contentWidth()
+	w := lipgloss.Width
+
+	auth := statusStyle.Render(ui.authInfo())
+	group := groupStyle.Render(ui.groupInfo())
+	expiry := expiryStyle.Render(ui.expiryInfo())
+	managerUrl := managerUrlStyle.Render("@ " + ui.manager.Host())
+	style := statusText
+	if ui.status.isErr {
+		style = statusErrorText
+	}
+	statusVal := style.Copy().
+		Width(width - w(auth) - w(group) - w(expiry) - w(managerUrl)).
+		MaxHeight(1).
+		Render(ui.statusInfo())
+
+	bar := lipgloss.JoinHorizontal(lipgloss.Top,
+		auth,
+		statusVal,
+		group,
+		expiry,
+		managerUrl,
+	)
+
+	return statusBarStyle.Width(width).Render(bar)
+}
+
+// errorView renders the error banner including the explanation sent by the
+// instance manager.
+func (ui UI) errorView() string {
+	var b strings.Builder
+	b.WriteString(errorTitle.Render("Error"))
+	var e *Error
+	if errors.As(ui.err.err, &e) {
+		b.WriteString(errorTitle.Render(fmt.Sprintf(": %s failed (%s)", e.Op, e.Status)))
+		if e.Message != "" {
+			b.WriteString("\n" + e.Message)
+		}
+	} else {
+		b.WriteString("\n" + ui.err.Error())
+	}
+
+	hints := []key.Binding{keys.Dismiss}
+	if ui.err.retry != nil {
+		hints = append([]key.Binding{keys.Retry}, hints...)
+	}
+	var hs []string
+	for _, h := range hints {
+		hs = append(hs, h.Help().Key+" "+h.Help().Desc)
+	}
+	b.WriteString("\n" + errorHint.Render(strings.Join(hs, " • ")))
+
+	return errorBanner.Width(max(0, ui.contentWidth()-2)).Render(b.String()) + "\n"
+}
 
-		doc.WriteString(statusBarStyle.Width(width).Render(bar))
+// authInfo returns the logged in user and the groups the user is a member
+// of.
+func (ui UI) authInfo() string {
+	if ui.user == nil {
+		return ui.manager.User()
+	}
+	var groups []string
+	for _, g := range ui.user.Groups {
+		groups = append(groups, g.Name)
+	}
+	if len(groups) == 0 {
+		return ui.user.Email
 	}
+	return fmt.Sprintf("%s (%s)", ui.user.Email, strings.Join(groups, ", "))
+}
 
-	if physicalWidth > 0 {
-		docStyle = docStyle.MaxWidth(physicalWidth)
+// statusInfo returns the current status message or the latency of the last
+// request if there is none.
+func (ui UI) statusInfo() string {
+	if ui.status.text != "" {
+		return ui.status.text
+	}
+	if l := ui.manager.Latency(); l > 0 {
+		return fmt.Sprintf("last request took %s", l.Round(time.Millisecond))
 	}
+	return ""
+}
 
-	return docStyle.Render(doc.String())
+// groupInfo returns the group the components are scoped to.
+func (ui UI) groupInfo() string {
+	if ui.group == "" {
+		return "all groups"
+	}
+	return "group " + ui.group
+}
+
+// expiryInfo returns the time left until the access token expires.
+func (ui UI) expiryInfo() string {
+	exp := ui.manager.TokenExpiry()
+	if exp.IsZero() {
+		return "token expiry unknown"
+	}
+	left := exp.Sub(ui.now)
+	if left <= 0 {
+		return "token expired"
+	}
+	return "token expires in " + left.Round(time.Second).String()
 }
 
 func max(a, b int) int {
@@ -0,0 +1,75 @@
+package instance
+
+import (
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestUI returns a UI showing the instance with an error banner.
+func newTestUI(t *testing.T, in Instance) tea.Model {
+	t.Helper()
+	im := NewManager("http://localhost", "user", "pw", nil)
+	var ui tea.Model = NewUI(im, NewInstances(im, DefaultLayout, 0))
+	ui, _ = ui.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	ui, _ = ui.Update(instancesMsg{instances: []Instance{in}})
+	ui, _ = ui.Update(errMsg{err: errors.New("failed")})
+	return ui
+}
+
+// typeKeys sends the runes as key presses.
+func typeKeys(ui tea.Model, runes string) tea.Model {
+	for _, r := range runes {
+		ui, _ = ui.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return ui
+}
+
+func TestGlobalKeysAreTypedIntoPrompt(t *testing.T) {
+	ui := newTestUI(t, Instance{ID: 1, Name: "play"})
+
+	ui = typeKeys(ui, "c")
+	ui = typeKeys(ui, "r")
+
+	got := ui.(UI)
+	if got.err == nil {
+		t.Fatal("typing r into the prompt retried the failed command")
+	}
+	if v := got.components[0].(instances).prompt.input.Value(); v != "play-cloner" {
+		t.Errorf("prompt value = %q, want %q", v, "play-cloner")
+	}
+
+	ui, _ = ui.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	got = ui.(UI)
+	if got.err == nil {
+		t.Error("esc in the prompt dismissed the error instead of cancelling the prompt")
+	}
+	if got.components[0].(instances).prompt.active {
+		t.Error("esc did not cancel the prompt")
+	}
+}
+
+func TestRetryKeyIsTypedIntoForm(t *testing.T) {
+	ui := newTestUI(t, Instance{
+		ID:             1,
+		Name:           "play",
+		OptionalParams: []InstanceParam{{Name: "IMAGE_TAG", Value: "2.40"}},
+	})
+
+	ui = typeKeys(ui, "e")
+	ui = typeKeys(ui, "-rc")
+
+	got := ui.(UI)
+	if got.err == nil {
+		t.Fatal("typing r into the form retried the failed command")
+	}
+	form := got.components[0].(instances).form
+	if !form.active {
+		t.Fatal("form is not active")
+	}
+	if v := form.inputs[0].Value(); v != "2.40-rc" {
+		t.Errorf("IMAGE_TAG = %q, want %q", v, "2.40-rc")
+	}
+}
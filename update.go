@@ -0,0 +1,84 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ParamChange is a change of the value of a parameter. Old is empty if the
+// parameter was not set before.
+type ParamChange struct {
+	Name string
+	Old  string
+	New  string
+	// Added is true if the parameter was not set before.
+	Added bool
+}
+
+// DiffParams returns the changes the params make to the parameters of the
+// instance sorted by name. Params equal to the current value are left out.
+func DiffParams(in Instance, params map[string]string) []ParamChange {
+	current := in.Params()
+	var changes []ParamChange
+	for name, v := range params {
+		old, ok := current[name]
+		if ok && old == v {
+			continue
+		}
+		changes = append(changes, ParamChange{Name: name, Old: old, New: v, Added: !ok})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+type updateBody struct {
+	RequiredParams []InstanceParam `json:"requiredParameters,omitempty"`
+	OptionalParams []InstanceParam `json:"optionalParameters,omitempty"`
+}
+
+// UpdateParameters sets the params of the instance and redeploys it.
+// Parameters that are not given keep their value. An error is returned if a
+// parameter is not defined by the stack of the instance.
+func (m *Manager) UpdateParameters(id int, params map[string]string) (*Instance, error) {
+	in, err := m.Instance(id)
+	if err != nil {
+		return nil, err
+	}
+	st, err := m.Stack(in.StackID)
+	if err != nil {
+		return nil, err
+	}
+	merged := in.Params()
+	for k, v := range params {
+		merged[k] = v
+	}
+	required, optional, err := st.assign(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(updateBody{RequiredParams: required, OptionalParams: optional})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPut, m.url+"/instances/"+strconv.Itoa(id), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		return nil, newError("update", http.StatusOK, resp)
+	}
+
+	return m.Instance(id)
+}
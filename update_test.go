@@ -0,0 +1,63 @@
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateParameters(t *testing.T) {
+	var got updateBody
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/instances/1":
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/instances/1":
+			fmt.Fprint(w, `{"ID":1,"Name":"dev","StackID":2,"RequiredParameters":[{"Name":"IMAGE_TAG","Value":"2.38"}],"OptionalParameters":[{"Name":"CHART_VERSION","Value":"1.0"}]}`)
+		case r.URL.Path == "/stacks/2":
+			fmt.Fprint(w, `{"ID":2,"name":"dhis2-core","requiredParameters":[{"Name":"IMAGE_TAG"}],"optionalParameters":[{"Name":"CHART_VERSION"},{"Name":"FLYWAY_REPAIR"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "user", "pw", srv.Client())
+
+	if _, err := m.UpdateParameters(1, map[string]string{"IMAGE_TAG": "2.39", "FLYWAY_REPAIR": "true"}); err != nil {
+		t.Fatalf("UpdateParameters failed: %s", err)
+	}
+
+	want := updateBody{
+		RequiredParams: []InstanceParam{{Name: "IMAGE_TAG", Value: "2.39"}},
+		OptionalParams: []InstanceParam{{Name: "CHART_VERSION", Value: "1.0"}, {Name: "FLYWAY_REPAIR", Value: "true"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Request body mismatch (-want +got): %s\n", diff)
+	}
+
+	if _, err := m.UpdateParameters(1, map[string]string{"UNKNOWN": "x"}); err == nil {
+		t.Error("UpdateParameters with unknown parameter succeeded, want error")
+	}
+}
+
+func TestDiffParams(t *testing.T) {
+	in := Instance{
+		RequiredParams: []InstanceParam{{Name: "IMAGE_TAG", Value: "2.38"}},
+		OptionalParams: []InstanceParam{{Name: "CHART_VERSION", Value: "1.0"}},
+	}
+
+	got := DiffParams(in, map[string]string{"IMAGE_TAG": "2.39", "CHART_VERSION": "1.0", "FLYWAY_REPAIR": "true"})
+
+	want := []ParamChange{
+		{Name: "FLYWAY_REPAIR", New: "true", Added: true},
+		{Name: "IMAGE_TAG", Old: "2.38", New: "2.39"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DiffParams() mismatch (-want +got): %s\n", diff)
+	}
+}
@@ -0,0 +1,195 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// AdministratorsGroup is the group of users administering the instance
+// manager.
+const AdministratorsGroup = "administrators"
+
+// ErrNotAdmin is returned if the user lacks the admin role an action
+// requires.
+var ErrNotAdmin = errors.New("permission denied")
+
+// IsAdministrator returns true if the user administers the instance manager.
+func (u *User) IsAdministrator() bool {
+	return u.IsMember(AdministratorsGroup)
+}
+
+// requireAdmin returns an error if the logged in user neither administers the
+// instance manager nor the group. An empty group requires the user to
+// administer the instance manager.
+func (m *Manager) requireAdmin(group string) error {
+	u, err := m.Me()
+	if err != nil {
+		return err
+	}
+	if u.IsAdministrator() {
+		return nil
+	}
+	if group == "" {
+		return fmt.Errorf("%w: %s is not a member of the %s group", ErrNotAdmin, u.Email, AdministratorsGroup)
+	}
+	if !u.IsAdmin(group) {
+		return fmt.Errorf("%w: %s is neither an admin of group %s nor a member of the %s group", ErrNotAdmin, u.Email, group, AdministratorsGroup)
+	}
+	return nil
+}
+
+// Users returns all users sorted by email. Only administrators can list
+// users.
+func (m *Manager) Users() ([]User, error) {
+	if err := m.requireAdmin(""); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, m.url+"/users", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError("fetching users", http.StatusOK, resp)
+	}
+
+	var us []User
+	if err := json.NewDecoder(resp.Body).Decode(&us); err != nil {
+		return nil, err
+	}
+	sort.Slice(us, func(i, j int) bool {
+		return us[i].Email < us[j].Email
+	})
+	return us, nil
+}
+
+// UserByEmail returns the user with given email.
+func (m *Manager) UserByEmail(email string) (*User, error) {
+	us, err := m.Users()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range us {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user %q not found", email)
+}
+
+// GroupUserID returns the ID of the user with given email or ID. Members of
+// the group are looked up in the group so that admins of the group can refer
+// to them by email. Only administrators can look up other users by email, for
+// example to add them to the group.
+func (m *Manager) GroupUserID(group, emailOrID string) (int, error) {
+	if id, err := strconv.Atoi(emailOrID); err == nil {
+		return id, nil
+	}
+	g, err := m.Group(group)
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range g.Users {
+		if u.Email == emailOrID {
+			return u.ID, nil
+		}
+	}
+	me, err := m.Me()
+	if err != nil {
+		return 0, err
+	}
+	if !me.IsAdministrator() {
+		return 0, fmt.Errorf("user %q is not a member of group %s: pass the id of the user as only members of the %s group can look up users by email", emailOrID, group, AdministratorsGroup)
+	}
+	u, err := m.UserByEmail(emailOrID)
+	if err != nil {
+		return 0, err
+	}
+	return u.ID, nil
+}
+
+// CreateUser creates a user with given email and password. Only
+// administrators can create users.
+func (m *Manager) CreateUser(email, password string) (*User, error) {
+	if err := m.requireAdmin(""); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{email, password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, m.url+"/users", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := m.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, newError("creating user", http.StatusCreated, resp)
+	}
+
+	u := &User{}
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// AddUserToGroup makes the user a member of the group.
+func (m *Manager) AddUserToGroup(group string, userID int) error {
+	return m.groupMembership("adding user to group", http.MethodPost, group, "users", userID)
+}
+
+// RemoveUserFromGroup removes the user from the members of the group.
+func (m *Manager) RemoveUserFromGroup(group string, userID int) error {
+	return m.groupMembership("removing user from group", http.MethodDelete, group, "users", userID)
+}
+
+// GrantGroupAdmin makes the user an admin of the group.
+func (m *Manager) GrantGroupAdmin(group string, userID int) error {
+	return m.groupMembership("granting group admin", http.MethodPost, group, "admins", userID)
+}
+
+// groupMembership adds or removes the user to or from the users or admins of
+// the group. Only administrators and admins of the group can change its
+// members.
+func (m *Manager) groupMembership(op, method, group, role string, userID int) error {
+	if err := m.requireAdmin(group); err != nil {
+		return err
+	}
+	u := m.url + "/groups/" + url.PathEscape(group) + "/" + role + "/" + strconv.Itoa(userID)
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		want := http.StatusCreated
+		if method == http.MethodDelete {
+			want = http.StatusNoContent
+		}
+		return newError(op, want, resp)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdminActionsRequireAdminRole(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/me" {
+			fmt.Fprint(w, `{"ID":1,"Email":"lead@dhis2.org","Groups":[{"Name":"qa"}],"AdminGroups":[{"Name":"qa"}]}`)
+			return
+		}
+		got = append(got, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "lead@dhis2.org", "pw", srv.Client())
+
+	if _, err := m.Users(); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("Users() returned %v, want ErrNotAdmin", err)
+	}
+	if err := m.AddUserToGroup("play", 2); !errors.Is(err, ErrNotAdmin) {
+		t.Errorf("AddUserToGroup of group user is no admin of returned %v, want ErrNotAdmin", err)
+	}
+	if err := m.AddUserToGroup("qa", 2); err != nil {
+		t.Errorf("AddUserToGroup of group user is admin of failed: %s", err)
+	}
+
+	want := []string{"POST /groups/qa/users/2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Requests mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestGroupUserID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/me":
+			fmt.Fprint(w, `{"ID":1,"Email":"lead@dhis2.org","Groups":[{"Name":"qa"}],"AdminGroups":[{"Name":"qa"}]}`)
+		case "/groups/qa":
+			fmt.Fprint(w, `{"ID":3,"Name":"qa","Users":[{"ID":1,"Email":"lead@dhis2.org"},{"ID":5,"Email":"dev@dhis2.org"}]}`)
+		default:
+			// only administrators can list users
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	m := NewManager(srv.URL, "lead@dhis2.org", "pw", srv.Client())
+
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "7", want: 7},
+		{in: "dev@dhis2.org", want: 5},
+	}
+	for _, tc := range tests {
+		got, err := m.GroupUserID("qa", tc.in)
+		if err != nil {
+			t.Fatalf("GroupUserID(%q) failed: %s", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("GroupUserID(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+
+	if _, err := m.GroupUserID("qa", "new@dhis2.org"); err == nil || !strings.Contains(err.Error(), "pass the id") {
+		t.Errorf("GroupUserID of a non-member returned %v, want an error asking for the id", err)
+	}
+}
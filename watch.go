@@ -0,0 +1,112 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type EventType int
+
+const (
+	Added EventType = iota
+	Updated
+	Deleted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// WatchEvent describes a change to an instance. Err is set instead if the
+// instances could not be fetched.
+type WatchEvent struct {
+	Type     EventType
+	Instance Instance
+	Err      error
+}
+
+// Watch polls the instances in given interval and sends an event for every
+// instance that was added, updated or deleted since the last poll. The
+// instances existing when Watch is called are sent as added. The channel is
+// closed once the context is done. The interval must be positive.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) (<-chan WatchEvent, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be positive")
+	}
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		known := make(map[int]Instance)
+		for {
+			ins, err := m.Instances()
+			if err != nil {
+				select {
+				case events <- WatchEvent{Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			} else {
+				cur := make(map[int]Instance)
+				for _, in := range ins {
+					cur[in.ID] = in
+				}
+				for _, e := range diffInstances(known, cur) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+				known = cur
+			}
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// diffInstances returns the events turning the old into the new instances
+// ordered by instance name.
+func diffInstances(old, cur map[int]Instance) []WatchEvent {
+	var events []WatchEvent
+	for id, in := range cur {
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			events = append(events, WatchEvent{Type: Added, Instance: in})
+		case !reflect.DeepEqual(prev, in):
+			events = append(events, WatchEvent{Type: Updated, Instance: in})
+		}
+	}
+	for id, in := range old {
+		if _, ok := cur[id]; !ok {
+			events = append(events, WatchEvent{Type: Deleted, Instance: in})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Instance.Name != events[j].Instance.Name {
+			return events[i].Instance.Name < events[j].Instance.Name
+		}
+		return events[i].Instance.ID < events[j].Instance.ID
+	})
+	return events
+}
@@ -0,0 +1,42 @@
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffInstances(t *testing.T) {
+	old := map[int]Instance{
+		1: {ID: 1, Name: "a", Status: "Running"},
+		2: {ID: 2, Name: "b", Status: "Pending"},
+		3: {ID: 3, Name: "c", Status: "Running"},
+	}
+	cur := map[int]Instance{
+		1: {ID: 1, Name: "a", Status: "Running"},
+		2: {ID: 2, Name: "b", Status: "Running"},
+		4: {ID: 4, Name: "d", Status: "Pending"},
+	}
+
+	var got []string
+	for _, e := range diffInstances(old, cur) {
+		got = append(got, e.Type.String()+" "+e.Instance.Name)
+	}
+
+	want := []string{"updated b", "deleted c", "added d"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diffInstances mismatch (-want +got): %s\n", diff)
+	}
+}
+
+func TestWatchRejectsNonPositiveInterval(t *testing.T) {
+	m := NewManager("http://localhost", "user", "password", nil)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := m.Watch(context.Background(), interval); err == nil {
+			t.Errorf("Watch(%s) expected an error", interval)
+		}
+	}
+}